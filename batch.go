@@ -0,0 +1,474 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 批量执行时可在字段值和模板中引用的当前输入文件，可加路径修饰符，如 ${input:stem}
+const batchVarName = "input"
+
+// 与路径修饰符对应的变量名，如 ${input_stem} 即 ${input:stem}
+var batchAliases = map[string]string{
+	"input_name": "base",
+	"input_stem": "stem",
+	"input_ext":  "ext",
+	"input_dir":  "dir",
+}
+
+// 批量输入字段，未启用批量执行时为 nil
+func (u *AppUI) batchItem() *Item {
+	if u.app.Batch == nil {
+		return nil
+	}
+	return u.findItem(u.app.Batch.Item)
+}
+
+// 批量输入字段总是多值，文件选择器可以一次选择多个
+func (u *AppUI) setupBatchItem() {
+	item := u.batchItem()
+	if item == nil {
+		return
+	}
+	item.Multi = true
+	if item.Picker == "" || item.Picker == "file" {
+		item.Picker = "files"
+	}
+}
+
+// 替换值中的 ${input} 等批量变量，其他内容（包括 $$）保持原样，
+// 与普通执行时的值一致。只在准备批量执行时生效
+func (u *AppUI) expandBatch(val string) string {
+	if u.batchInput == "" {
+		return val
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(val, "${")
+		if i < 0 {
+			break
+		}
+		end := strings.IndexByte(val[i+2:], '}')
+		if end < 0 {
+			break
+		}
+		ref := val[i+2 : i+2+end]
+		b.WriteString(val[:i])
+		if v, ok := u.batchValue(ref); ok {
+			b.WriteString(v)
+		} else {
+			b.WriteString(val[i : i+3+end])
+		}
+		val = val[i+3+end:]
+	}
+	b.WriteString(val)
+	return b.String()
+}
+
+// 引用的批量变量，按路径修饰符取值
+func (u *AppUI) batchValue(ref string) (string, bool) {
+	if u.batchInput == "" {
+		return "", false
+	}
+	name, mod := splitTemplateRef(ref)
+	if alias, ok := batchAliases[name]; ok {
+		return applyPathModifier(applyPathModifier(u.batchInput, alias), mod), true
+	}
+	if name != batchVarName {
+		return "", false
+	}
+	return applyPathModifier(u.batchInput, mod), true
+}
+
+// 是否为批量变量名
+func isBatchVar(name string) bool {
+	_, ok := batchAliases[name]
+	return ok || name == batchVarName
+}
+
+// 将输入字段的值展开为文件列表: 目录按 glob 匹配，含通配符的按模式匹配
+func expandBatchInputs(vals []string, glob string) ([]string, error) {
+	if glob == "" {
+		glob = "*"
+	}
+	var inputs []string
+	add := func(paths ...string) {
+		for _, p := range paths {
+			if !slices.Contains(inputs, p) {
+				inputs = append(inputs, p)
+			}
+		}
+	}
+	for _, val := range vals {
+		pattern := val
+		if info, err := os.Stat(val); err == nil && info.IsDir() {
+			pattern = filepath.Join(val, glob)
+		} else if !strings.ContainsAny(val, "*?[") {
+			add(val)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		sort.Strings(matches)
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && !info.IsDir() {
+				add(m)
+			}
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no input files")
+	}
+	return inputs, nil
+}
+
+const (
+	batchPending  = "pending"
+	batchRunning  = "running"
+	batchDone     = "done"
+	batchFailed   = "failed"
+	batchCanceled = "canceled"
+)
+
+// 批量执行中的一个输入
+type batchJob struct {
+	input    string
+	spec     *runSpec
+	dir      string
+	status   string
+	exitCode int
+	duration time.Duration
+	output   string
+	// 执行中或排队的任务
+	job *Job
+}
+
+// 一次批量执行
+type batchRun struct {
+	u        *AppUI
+	parallel int
+	mu       sync.Mutex
+	jobs     []*batchJob
+	canceled bool
+	// 状态变化时在 UI 线程调用
+	onUpdate func()
+}
+
+// 为每个输入准备参数，需要在 UI 线程调用
+func (u *AppUI) prepareBatch(inputs []string) ([]*batchJob, error) {
+	defer func() { u.batchInput = "" }()
+	var jobs []*batchJob
+	release := func() {
+		for _, j := range jobs {
+			j.spec.release()
+		}
+	}
+	for _, input := range inputs {
+		u.batchInput = input
+		dir, err := u.workDir()
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("%s: %w", input, err)
+		}
+		// 文件在开始执行时才写入或打开，避免输入很多时同时打开过多文件
+		spec, err := u.planRun()
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("%s: %w", input, err)
+		}
+		jobs = append(jobs, &batchJob{input: input, spec: spec, dir: dir, status: batchPending})
+	}
+	return jobs, nil
+}
+
+func (u *AppUI) executeBatch() {
+	item := u.batchItem()
+	inputs, err := expandBatchInputs(u.itemValues(item), u.app.Batch.Glob)
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+	jobs, err := u.prepareBatch(inputs)
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+	b := &batchRun{u: u, parallel: max(1, u.app.Batch.Parallel), jobs: jobs}
+	b.show()
+	b.start(jobs)
+}
+
+// 执行给定的任务，同时最多执行 parallel 个
+func (b *batchRun) start(jobs []*batchJob) {
+	b.mu.Lock()
+	b.canceled = false
+	b.mu.Unlock()
+	// 关闭窗口时停止开始新的任务，并等待全部结束
+	unregister := b.u.jobs.register(b)
+	sem := make(chan struct{}, b.parallel)
+	go func() {
+		defer unregister()
+		var wg sync.WaitGroup
+		for _, j := range jobs {
+			sem <- struct{}{}
+			if b.isCanceled() {
+				<-sem
+				b.setStatus(j, batchCanceled)
+				j.spec.release()
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b.runJob(j)
+				<-sem
+			}()
+		}
+		wg.Wait()
+		b.update()
+	}()
+}
+
+func (b *batchRun) isCanceled() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.canceled
+}
+
+func (b *batchRun) setStatus(j *batchJob, status string) {
+	b.mu.Lock()
+	j.status = status
+	b.mu.Unlock()
+	b.update()
+}
+
+func (b *batchRun) update() {
+	if b.onUpdate != nil {
+		fyne.Do(b.onUpdate)
+	}
+}
+
+func (b *batchRun) runJob(j *batchJob) {
+	u := b.u
+	if err := j.spec.open(); err != nil {
+		b.mu.Lock()
+		j.status = batchFailed
+		j.exitCode = -1
+		j.output = err.Error()
+		b.mu.Unlock()
+		b.update()
+		return
+	}
+	cmd := u.newCommand(j.spec, u.app.Command.Env, j.dir)
+	entry := u.newHistoryEntry(j.spec, u.app.Command.Env, j.dir)
+	job := u.jobs.add(u, cmd, entry)
+	cmd.Stdout = job.output
+	cmd.Stderr = job.output
+
+	b.mu.Lock()
+	j.job = job
+	canceled := b.canceled
+	b.mu.Unlock()
+	if canceled {
+		u.jobs.cancel(job)
+	}
+
+	err := u.jobs.start(job)
+	if err == nil {
+		b.setStatus(j, batchRunning)
+		err = cmd.Wait()
+	}
+	u.finishRun(entry, err, job.output.String())
+	u.jobs.finish(job, err)
+
+	b.mu.Lock()
+	j.exitCode = entry.ExitCode
+	j.duration = entry.Duration
+	j.output = job.output.String()
+	j.job = nil
+	switch {
+	case err == nil:
+		j.status = batchDone
+	case b.canceled || u.jobs.state(job) == jobCanceled:
+		j.status = batchCanceled
+	default:
+		j.status = batchFailed
+	}
+	b.mu.Unlock()
+	b.update()
+}
+
+// 停止执行中的任务，不再开始新的任务
+func (b *batchRun) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.canceled = true
+	for _, j := range b.jobs {
+		if j.job != nil {
+			b.u.jobs.cancel(j.job)
+		}
+	}
+}
+
+// 不再开始新的任务，执行中的任务由 jobManager 取消
+func (b *batchRun) stop() {
+	b.mu.Lock()
+	b.canceled = true
+	b.mu.Unlock()
+}
+
+// 还未开始的输入数
+func (b *batchRun) pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, j := range b.jobs {
+		if j.status == batchPending && j.job == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// 已结束、失败和总任务数，以及是否全部结束
+func (b *batchRun) counts() (finished, failed, total int, idle bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	idle = true
+	for _, j := range b.jobs {
+		switch j.status {
+		case batchDone:
+			finished++
+		case batchFailed, batchCanceled:
+			finished++
+			failed++
+		default:
+			idle = false
+		}
+	}
+	return finished, failed, len(b.jobs), idle
+}
+
+// 重新执行失败和取消的任务，参数按当前表单重新生成
+func (b *batchRun) retryFailed() error {
+	b.mu.Lock()
+	var inputs []string
+	var idx []int
+	for i, j := range b.jobs {
+		if j.status == batchFailed || j.status == batchCanceled {
+			inputs = append(inputs, j.input)
+			idx = append(idx, i)
+		}
+	}
+	b.mu.Unlock()
+	if len(inputs) == 0 {
+		return nil
+	}
+	jobs, err := b.u.prepareBatch(inputs)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	for k, i := range idx {
+		b.jobs[i] = jobs[k]
+	}
+	b.mu.Unlock()
+	b.start(jobs)
+	return nil
+}
+
+// 一行的显示文本
+func (j *batchJob) summary() string {
+	s := fmt.Sprintf("[%s] %s", j.status, filepath.Base(j.input))
+	if j.status == batchDone || j.status == batchFailed {
+		s += fmt.Sprintf("  exit %d  %s", j.exitCode, j.duration.Round(100*time.Millisecond))
+	}
+	return s
+}
+
+// 批量执行进度窗口
+func (b *batchRun) show() {
+	progress := widget.NewProgressBar()
+	status := widget.NewLabel("")
+	detail := widget.NewMultiLineEntry()
+	detail.Wrapping = fyne.TextWrapWord
+	selected := -1
+
+	list := widget.NewList(
+		func() int {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			return len(b.jobs)
+		},
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			b.mu.Lock()
+			text := b.jobs[id].summary()
+			b.mu.Unlock()
+			obj.(*widget.Label).SetText(text)
+		},
+	)
+	showDetail := func() {
+		if selected < 0 {
+			return
+		}
+		b.mu.Lock()
+		j := b.jobs[selected]
+		text := j.input + "\n" + strings.Join(j.spec.masked, " ") + "\n\n" + j.output
+		b.mu.Unlock()
+		detail.SetText(text)
+	}
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		showDetail()
+	}
+
+	cancelBtn := widget.NewButton("Cancel", b.cancel)
+	cancelBtn.Importance = widget.DangerImportance
+	retryBtn := widget.NewButton("Retry Failed", nil)
+	win := fyne.CurrentApp().NewWindow("Batch")
+	retryBtn.OnTapped = func() {
+		if err := b.retryFailed(); err != nil {
+			dialog.ShowError(err, win)
+		}
+	}
+
+	b.onUpdate = func() {
+		finished, failed, total, idle := b.counts()
+		progress.SetValue(float64(finished) / float64(total))
+		status.SetText(fmt.Sprintf("%d / %d finished, %d failed", finished, total, failed))
+		if idle {
+			cancelBtn.Disable()
+		} else {
+			cancelBtn.Enable()
+		}
+		if idle && failed > 0 {
+			retryBtn.Enable()
+		} else {
+			retryBtn.Disable()
+		}
+		list.Refresh()
+		showDetail()
+	}
+	b.onUpdate()
+
+	top := container.NewBorder(nil, nil, nil, container.NewHBox(retryBtn, cancelBtn), container.NewVBox(progress, status))
+	split := container.NewVSplit(list, container.NewScroll(detail))
+	split.Offset = 0.6
+	win.SetContent(container.NewBorder(top, nil, nil, nil, split))
+	win.Resize(fyne.NewSize(600, 500))
+	win.Show()
+}
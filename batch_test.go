@@ -0,0 +1,258 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestExpandBatch(t *testing.T) {
+	ui := NewAppUI(&App{Command: Command{Path: "cmd"}}, test.NewWindow(nil))
+	if got := ui.expandBatch("${input:stem}"); got != "${input:stem}" {
+		t.Errorf("expandBatch() outside batch = %q", got)
+	}
+	ui.batchInput = "/media/clip.final.mov"
+	tests := map[string]string{
+		"${input}":                "/media/clip.final.mov",
+		"${input:base}":           "clip.final.mov",
+		"${input:stem}":           "clip.final",
+		"${input:ext}":            ".mov",
+		"${input:dir}/out.mp4":    "/media/out.mp4",
+		"${input_name}":           "clip.final.mov",
+		"${input_stem}":           "clip.final",
+		"${input_ext}":            ".mov",
+		"${input_dir}/out.mp4":    "/media/out.mp4",
+		"${input_dir:base}":       "media",
+		"$$5 ${input:stem} $HOME": "$$5 clip.final $HOME",
+		"${other} ${input:size}":  "${other} ${input:size}",
+		"${input:stem":            "${input:stem",
+	}
+	for in, want := range tests {
+		if got := ui.expandBatch(in); got != filepath.FromSlash(want) {
+			t.Errorf("expandBatch(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExpandBatchInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.mov", "a.mov", "c.txt"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0o644)
+	}
+	os.Mkdir(filepath.Join(dir, "sub.mov"), 0o755)
+	a, b, c := filepath.Join(dir, "a.mov"), filepath.Join(dir, "b.mov"), filepath.Join(dir, "c.txt")
+
+	got, err := expandBatchInputs([]string{dir, filepath.Join(dir, "*.txt"), a}, "*.mov")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 目录按 glob 匹配并排序，跳过子目录和重复的文件
+	if want := []string{a, b, c}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expandBatchInputs() = %v, want %v", got, want)
+	}
+
+	if _, err := expandBatchInputs([]string{filepath.Join(dir, "*.mp4")}, ""); err == nil {
+		t.Error("expandBatchInputs() without matches should fail")
+	}
+}
+
+func TestPrepareBatch(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "ffmpeg"},
+		Batch:   &Batch{Item: "i"},
+		Items: []Item{
+			{Name: "i", Type: "string", Short: true, Separator: " ", Picker: "file"},
+			{Name: "vf", Type: "string", Template: []string{"-vf", "drawtext=text=${input:base}"}},
+			{Name: "out", Type: "string", Positional: true},
+		},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.Build()
+
+	if !app.Items[0].Multi || app.Items[0].Picker != "files" {
+		t.Errorf("batch item = %+v, want multi files picker", app.Items[0])
+	}
+	setEntryText(ui.widgets["vf"], "on")
+	setEntryText(ui.widgets["out"], "${input:dir}/${input:stem}.mp4")
+
+	jobs, err := ui.prepareBatch([]string{"/v/a.mov", "/v/b.mkv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"-i", "/v/a.mov", "-vf", "drawtext=text=a.mov", "/v/a.mp4"},
+		{"-i", "/v/b.mkv", "-vf", "drawtext=text=b.mkv", "/v/b.mp4"},
+	}
+	for i, j := range jobs {
+		if !reflect.DeepEqual(j.spec.args, want[i]) {
+			t.Errorf("jobs[%d] args = %v, want %v", i, j.spec.args, want[i])
+		}
+	}
+	if ui.batchInput != "" {
+		t.Error("batchInput should be reset after preparing")
+	}
+}
+
+// 等待批量执行全部结束
+func waitBatch(t *testing.T, b *batchRun) (finished, failed int) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		finished, failed, total, idle := b.counts()
+		if idle && finished == total {
+			return finished, failed
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("batch did not finish")
+	return 0, 0
+}
+
+func TestBatchRunRetryFailed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	dir := t.TempDir()
+	app := &App{
+		Command: Command{Path: "sh", Name: "Shell", Args: []string{"-c", `test -e "$0.ok"`}},
+		Batch:   &Batch{Item: "in", Parallel: 2},
+		Items:   []Item{{Name: "in", Type: "string", Positional: true}},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.setStore(nil, 0)
+	ui.history = openHistory(t.TempDir(), "/tmp/tools.toml")
+	ui.Build()
+
+	a, b, c := filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c")
+	os.WriteFile(a+".ok", nil, 0o644)
+	jobs, err := ui.prepareBatch([]string{a, b, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := &batchRun{u: ui, parallel: 2, jobs: jobs}
+	run.start(jobs)
+	if finished, failed := waitBatch(t, run); finished != 3 || failed != 2 {
+		t.Fatalf("finished = %d, failed = %d, want 3, 2", finished, failed)
+	}
+	if jobs[1].status != batchFailed || jobs[1].exitCode != 1 {
+		t.Errorf("jobs[1] = %s exit %d, want failed exit 1", jobs[1].status, jobs[1].exitCode)
+	}
+
+	os.WriteFile(b+".ok", nil, 0o644)
+	if err := run.retryFailed(); err != nil {
+		t.Fatal(err)
+	}
+	if finished, failed := waitBatch(t, run); finished != 3 || failed != 1 {
+		t.Errorf("after retry finished = %d, failed = %d, want 3, 1", finished, failed)
+	}
+	if n := len(ui.history.Entries("Shell")); n != 5 {
+		t.Errorf("history entries = %d, want 5", n)
+	}
+}
+
+func TestBatchRunCancelAll(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	app := &App{
+		Command: Command{Path: "sh", Name: "Shell", Args: []string{"-c", "sleep 10"}},
+		Batch:   &Batch{Item: "in"},
+		Items:   []Item{{Name: "in", Type: "string", Positional: true}},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.setStore(nil, 0)
+	ui.Build()
+
+	jobs, err := ui.prepareBatch([]string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := &batchRun{u: ui, parallel: 1, jobs: jobs}
+	run.start(jobs)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		run.mu.Lock()
+		status := jobs[0].status
+		run.mu.Unlock()
+		if status == batchRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("first input did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// 等待中的输入也算作未结束的任务
+	if n := ui.jobs.activeCount(); n != 3 {
+		t.Errorf("activeCount() = %d, want 3", n)
+	}
+
+	ui.jobs.cancelAll()
+	ui.jobs.waitAll()
+	for i, j := range jobs {
+		if j.status != batchCanceled {
+			t.Errorf("jobs[%d] status = %s, want canceled", i, j.status)
+		}
+	}
+	if n := len(ui.jobs.Jobs()); n != 1 {
+		t.Errorf("registered jobs = %d, want 1", n)
+	}
+}
+
+func TestBatchOpensFilesWhenStarting(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	dir := t.TempDir()
+	app := &App{
+		Command: Command{Path: "sh", Name: "Shell", Args: []string{"-c", `cat; cat "$1"`}, Stdin: &Stdin{File: "${input}"}},
+		Batch:   &Batch{Item: "in"},
+		Items: []Item{
+			{Name: "in", Type: "string", Positional: true},
+			{Name: "note", Type: "text", AsFile: true, Positional: true},
+		},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.setStore(nil, 0)
+	ui.Build()
+	setEntryText(ui.widgets["note"], "!")
+
+	ok := filepath.Join(dir, "ok.txt")
+	os.WriteFile(ok, []byte("data"), 0o644)
+	jobs, err := ui.prepareBatch([]string{ok, filepath.Join(dir, "missing.txt")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 准备时只确定路径，不打开文件也不写入临时文件
+	for i, j := range jobs {
+		if j.spec.stdin != nil || len(j.spec.tempFiles) != 0 {
+			t.Errorf("jobs[%d] opened files before starting", i)
+		}
+		if _, err := os.Stat(j.spec.texts[0].path); err == nil {
+			t.Errorf("jobs[%d] temp file written before starting", i)
+		}
+	}
+
+	run := &batchRun{u: ui, parallel: 1, jobs: jobs}
+	temp := jobs[0].spec.texts[0].path
+	run.start(jobs)
+	if finished, failed := waitBatch(t, run); finished != 2 || failed != 1 {
+		t.Fatalf("finished = %d, failed = %d, want 2, 1", finished, failed)
+	}
+	ui.jobs.waitAll()
+	if jobs[0].output != "data!" {
+		t.Errorf("jobs[0] output = %q, want %q", jobs[0].output, "data!")
+	}
+	if !strings.Contains(jobs[1].output, "open stdin file") {
+		t.Errorf("jobs[1] output = %q, want stdin error", jobs[1].output)
+	}
+	if _, err := os.Stat(temp); !os.IsNotExist(err) {
+		t.Errorf("temp file %s not removed", temp)
+	}
+}
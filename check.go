@@ -0,0 +1,707 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 配置诊断信息
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+func (d Diagnostic) String() string {
+	pos := d.File
+	if d.Line > 0 {
+		pos = fmt.Sprintf("%s:%d", pos, d.Line)
+	}
+	if d.Key != "" {
+		return fmt.Sprintf("%s: %s: %s: %s", pos, d.Severity, d.Key, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", pos, d.Severity, d.Message)
+}
+
+type Diagnostics []Diagnostic
+
+func (ds Diagnostics) HasErrors() bool {
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (ds Diagnostics) String() string {
+	var lines []string
+	for _, d := range ds {
+		lines = append(lines, d.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+var itemTypes = map[string]bool{
+	"string":      true,
+	"number":      true,
+	"password":    true,
+	"text":        true,
+	"bool":        true,
+	"choice":      true,
+	"multichoice": true,
+}
+
+var choiceStyles = map[string]bool{
+	"":       true,
+	"select": true,
+	"radio":  true,
+	"auto":   true,
+}
+
+var commandModes = map[string]bool{
+	"hidden":  true,
+	"visible": true,
+}
+
+var outputModes = map[string]bool{
+	"dialog":           true,
+	"realtime":         true,
+	"realtime-console": true,
+}
+
+var conditionModes = map[string]bool{
+	"":        true,
+	"disable": true,
+	"hide":    true,
+}
+
+var positions = map[string]bool{
+	"":       true,
+	"before": true,
+	"after":  true,
+}
+
+var pickers = map[string]bool{
+	"file":      true,
+	"save":      true,
+	"files":     true,
+	"directory": true,
+}
+
+var importances = map[string]bool{
+	"":        true,
+	"high":    true,
+	"danger":  true,
+	"warning": true,
+	"success": true,
+	"low":     true,
+}
+
+// 配置语义检查
+type configChecker struct {
+	file  string
+	lines map[string]int
+	diags Diagnostics
+	// 当前 app 的批量执行设置，启用时可以引用 ${input} 等变量
+	batch *Batch
+	// 当前 app 默认的 condition_mode
+	conditionMode string
+}
+
+// 模板中引用的名称是否为字段或批量变量
+func (c *configChecker) knownRef(names map[string]int, name string) bool {
+	if _, ok := names[name]; ok {
+		return true
+	}
+	return c.batch != nil && isBatchVar(name)
+}
+
+func (c *configChecker) add(severity, key, format string, args ...any) {
+	c.diags = append(c.diags, Diagnostic{
+		File:     c.file,
+		Line:     c.lineOf(key),
+		Key:      key,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *configChecker) errorf(key, format string, args ...any) {
+	c.add(SeverityError, key, format, args...)
+}
+
+func (c *configChecker) warnf(key, format string, args ...any) {
+	c.add(SeverityWarning, key, format, args...)
+}
+
+// 查找键所在行，找不到时回退到所属表
+func (c *configChecker) lineOf(key string) int {
+	for key != "" {
+		if n, ok := c.lines[key]; ok {
+			return n
+		}
+		// 数组元素没有单独的行号时使用数组所在行
+		if j := strings.LastIndex(key, "["); strings.HasSuffix(key, "]") && j > strings.LastIndex(key, ".") {
+			key = key[:j]
+			continue
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return 0
+}
+
+func (c *configChecker) checkConfig(cfg *Config) {
+	if len(cfg.Apps) == 0 {
+		c.warnf("apps", "no apps defined")
+	}
+	if cfg.MaxJobs < 0 {
+		c.errorf("max_jobs", "max_jobs must not be negative")
+	}
+	for i := range cfg.Apps {
+		c.checkApp(fmt.Sprintf("apps[%d]", i), &cfg.Apps[i])
+	}
+}
+
+func (c *configChecker) checkApp(key string, app *App) {
+	if app.Command.Path == "" {
+		c.errorf(key+".command.path", "command path is required")
+	}
+	if !commandModes[app.Command.Mode] {
+		c.errorf(key+".command.mode", "unknown mode %q", app.Command.Mode)
+	}
+	if !outputModes[app.Command.Output] {
+		c.errorf(key+".command.output", "unknown output %q", app.Command.Output)
+	}
+	if !importances[app.Command.RunColor] {
+		c.warnf(key+".command.run_color", "unknown color %q", app.Command.RunColor)
+	}
+	if !importances[app.Command.DebugColor] {
+		c.warnf(key+".command.debug_color", "unknown color %q", app.Command.DebugColor)
+	}
+	if !conditionModes[app.Command.ConditionMode] {
+		c.errorf(key+".command.condition_mode", "unknown condition_mode %q", app.Command.ConditionMode)
+	}
+	if app.Command.MaxJobs < 0 {
+		c.errorf(key+".command.max_jobs", "max_jobs must not be negative")
+	}
+	if sig := app.Command.StopSignal; sig != "" && !stopSignals[normalizeSignal(sig)] {
+		c.errorf(key+".command.stop_signal", "unknown stop_signal %q", sig)
+	}
+	if t := app.Command.StopTimeout; t != "" {
+		if d, err := time.ParseDuration(t); err != nil || d < 0 {
+			c.errorf(key+".command.stop_timeout", "invalid stop_timeout %q, expected a duration like \"5s\"", t)
+		}
+	}
+
+	names := make(map[string]int)
+	for i := range app.Items {
+		item := &app.Items[i]
+		if item.Name == "" {
+			continue
+		}
+		if first, ok := names[item.Name]; ok {
+			c.errorf(fmt.Sprintf("%s.items[%d].name", key, i), "duplicate name %q (first defined at items[%d])", item.Name, first)
+			continue
+		}
+		names[item.Name] = i
+	}
+
+	c.batch = app.Batch
+	c.conditionMode = app.Command.ConditionMode
+	c.checkBatch(key+".batch", app.Batch, app.Items, names)
+
+	stdin := -1
+	for i := range app.Items {
+		c.checkItem(fmt.Sprintf("%s.items[%d]", key, i), &app.Items[i], names)
+		if app.Items[i].Type == "text" && app.Items[i].Stdin {
+			if app.Command.Stdin != nil {
+				c.errorf(fmt.Sprintf("%s.items[%d].stdin", key, i), "conflicts with command.stdin")
+			} else if stdin >= 0 {
+				c.errorf(fmt.Sprintf("%s.items[%d].stdin", key, i), "only one item can feed stdin (first defined at items[%d])", stdin)
+			} else {
+				stdin = i
+			}
+		}
+	}
+
+	c.checkStdin(key+".command.stdin", app.Command.Stdin, names)
+	c.checkVisible(key, app)
+
+	presets := make(map[string]bool)
+	for i := range app.Presets {
+		pkey := fmt.Sprintf("%s.presets[%d]", key, i)
+		p := &app.Presets[i]
+		if p.Name == "" {
+			c.errorf(pkey+".name", "preset name is required")
+		} else if presets[p.Name] {
+			c.errorf(pkey+".name", "duplicate preset %q", p.Name)
+		}
+		presets[p.Name] = true
+		for field, v := range p.Values {
+			idx, ok := names[field]
+			if !ok {
+				c.errorf(pkey+".values."+field, "preset references unknown field %q", field)
+				continue
+			}
+			item := &app.Items[idx]
+			if item.Type != "choice" && item.Type != "multichoice" {
+				continue
+			}
+			for _, val := range presetItemValues(v) {
+				if !slices.Contains(item.Choices, val) {
+					c.errorf(pkey+".values."+field, "value %q is not among choices", val)
+				}
+			}
+		}
+	}
+}
+
+func (c *configChecker) checkBatch(key string, b *Batch, items []Item, names map[string]int) {
+	if b == nil {
+		return
+	}
+	if b.Item == "" {
+		c.errorf(key+".item", "batch item is required")
+	} else if idx, ok := names[b.Item]; !ok {
+		c.errorf(key+".item", "batch references unknown field %q", b.Item)
+	} else if items[idx].Type != "string" {
+		c.errorf(key+".item", "batch item must be of type \"string\", got %q", items[idx].Type)
+	}
+	if _, err := filepath.Match(b.Glob, ""); err != nil {
+		c.errorf(key+".glob", "invalid glob %q", b.Glob)
+	}
+	if b.Parallel < 0 {
+		c.errorf(key+".parallel", "parallel must not be negative")
+	}
+}
+
+// visible 模式在 macOS 和 Windows 上通过其他程序启动命令，无法提供标准输入和临时文件
+func (c *configChecker) checkVisible(key string, app *App) {
+	if app.Command.Mode != "visible" {
+		return
+	}
+	if app.Command.Stdin != nil {
+		c.errorf(key+".command.stdin", "stdin is not supported with mode \"visible\"")
+	}
+	for i := range app.Items {
+		item := &app.Items[i]
+		if item.Type != "text" {
+			continue
+		}
+		if item.AsFile {
+			c.errorf(fmt.Sprintf("%s.items[%d].as_file", key, i), "as_file is not supported with mode \"visible\"")
+		}
+		if item.Stdin {
+			c.errorf(fmt.Sprintf("%s.items[%d].stdin", key, i), "stdin is not supported with mode \"visible\"")
+		}
+	}
+}
+
+func (c *configChecker) checkStdin(key string, in *Stdin, names map[string]int) {
+	if in == nil {
+		return
+	}
+	set := 0
+	for _, v := range []string{in.Text, in.Item, in.File} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		c.errorf(key, "stdin needs exactly one of text, item or file")
+		return
+	}
+	refs := templateNames(in.Text + in.File)
+	if in.Item != "" {
+		refs = []string{in.Item}
+	}
+	for _, ref := range refs {
+		name, _ := splitTemplateRef(ref)
+		if !c.knownRef(names, name) {
+			c.errorf(key, "stdin references unknown field %q", ref)
+		}
+	}
+}
+
+func (c *configChecker) checkBool(key string, item *Item) {
+	fields := []struct {
+		name string
+		set  bool
+	}{
+		{"true_value", item.TrueValue != ""},
+		{"false_value", item.FalseValue != ""},
+		{"on", item.On != nil},
+		{"off", item.Off != nil},
+		{"tristate", item.Tristate},
+	}
+	for _, f := range fields {
+		switch {
+		case !f.set:
+		case item.Type != "bool":
+			c.warnf(key+"."+f.name, "%s is ignored for type %q", f.name, item.Type)
+		case item.Template != nil && f.name != "tristate":
+			c.warnf(key+"."+f.name, "%s is ignored when template is set", f.name)
+		}
+	}
+	if item.On != nil && item.TrueValue != "" {
+		c.warnf(key+".true_value", "true_value is ignored when on is set")
+	}
+	if item.Off != nil && item.FalseValue != "" {
+		c.warnf(key+".false_value", "false_value is ignored when off is set")
+	}
+}
+
+func (c *configChecker) checkText(key string, item *Item) {
+	if item.Type != "text" {
+		if item.Rows != 0 {
+			c.warnf(key+".rows", "rows is ignored for type %q", item.Type)
+		}
+		if item.AsFile {
+			c.warnf(key+".as_file", "as_file is ignored for type %q", item.Type)
+		}
+		if item.Stdin {
+			c.warnf(key+".stdin", "stdin is ignored for type %q", item.Type)
+		}
+		return
+	}
+	if item.Rows < 0 {
+		c.errorf(key+".rows", "rows must be positive")
+	}
+	if item.AsFile && item.Stdin {
+		c.errorf(key+".stdin", "as_file and stdin cannot be used together")
+	}
+	if item.Multi && item.Indirect() {
+		c.errorf(key+".multi", "multi is not supported with as_file or stdin")
+	}
+}
+
+func (c *configChecker) checkMulti(key string, item *Item) {
+	if !item.MultiValued() {
+		if item.MinCount != 0 {
+			c.warnf(key+".min_count", "min_count is ignored without multi")
+		}
+		if item.MaxCount != 0 {
+			c.warnf(key+".max_count", "max_count is ignored without multi")
+		}
+		if item.Join != "" {
+			c.warnf(key+".join", "join is ignored without multi")
+		}
+		return
+	}
+	if item.Multi && (item.Type == "bool" || item.Type == "multichoice") {
+		c.warnf(key+".multi", "multi has no effect for type %q", item.Type)
+	}
+	if item.MinCount < 0 {
+		c.errorf(key+".min_count", "min_count must not be negative")
+	}
+	if item.MaxCount < 0 {
+		c.errorf(key+".max_count", "max_count must not be negative")
+	} else if item.MaxCount > 0 && item.MaxCount < item.MinCount {
+		c.errorf(key+".max_count", "max_count %d is less than min_count %d", item.MaxCount, item.MinCount)
+	}
+}
+
+func (c *configChecker) checkItem(key string, item *Item, names map[string]int) {
+	if item.IsLabel() {
+		return
+	}
+	if item.Name == "" {
+		c.errorf(key, "item needs either name or text")
+		return
+	}
+
+	if item.Type == "" {
+		c.errorf(key+".type", "type is required")
+	} else if !itemTypes[item.Type] {
+		c.errorf(key+".type", "unknown type %q", item.Type)
+	}
+
+	if len(item.Choices) > 0 && item.Type != "choice" && item.Type != "multichoice" {
+		c.warnf(key+".choices", "choices is ignored for type %q", item.Type)
+	}
+	if !choiceStyles[item.Style] {
+		c.errorf(key+".style", "unknown style %q", item.Style)
+	} else if item.Style != "" && item.Type != "choice" {
+		c.warnf(key+".style", "style is ignored for type %q", item.Type)
+	}
+	if item.Type == "multichoice" && len(item.Choices) == 0 {
+		c.errorf(key+".choices", "multichoice needs choices")
+	}
+
+	if item.Validate != "" {
+		if _, err := regexp.Compile(item.Validate); err != nil {
+			c.errorf(key+".validate", "invalid regex: %v", err)
+		}
+	}
+
+	c.checkText(key, item)
+	c.checkBool(key, item)
+	c.checkMulti(key, item)
+	if !positions[item.Position] {
+		c.errorf(key+".position", "unknown position %q", item.Position)
+	}
+	for field, tmpl := range map[string][]string{"template": item.Template, "on": item.On, "off": item.Off} {
+		for i, tok := range tmpl {
+			for _, ref := range templateNames(tok) {
+				name, _ := splitTemplateRef(ref)
+				if !c.knownRef(names, name) && name != "value" {
+					c.errorf(fmt.Sprintf("%s.%s[%d]", key, field, i), "%s references unknown field %q", field, ref)
+				}
+			}
+		}
+	}
+	for _, ref := range templateNames(item.Suggest) {
+		name, _ := splitTemplateRef(ref)
+		if !c.knownRef(names, name) {
+			c.errorf(key+".suggest", "suggest references unknown field %q", ref)
+		}
+	}
+	if item.Suggest != "" && item.MultiValued() {
+		c.warnf(key+".suggest", "suggest is ignored for multi-value fields")
+	}
+	c.checkSeparator(key, item)
+	c.checkPicker(key, item)
+	c.checkRange(key, item)
+	c.checkDefault(key, item)
+
+	if !conditionModes[item.ConditionMode] {
+		c.errorf(key+".condition_mode", "unknown condition_mode %q", item.ConditionMode)
+	} else if item.ConditionMode != "" && item.Condition == "" {
+		c.warnf(key+".condition_mode", "condition_mode has no effect without condition")
+	}
+	if item.EmitWhenDisabled {
+		if item.Condition == "" {
+			c.warnf(key+".emit_when_disabled", "emit_when_disabled has no effect without condition")
+		} else if item.ConditionMode == "hide" || item.ConditionMode == "" && c.conditionMode == "hide" {
+			c.warnf(key+".emit_when_disabled", "emit_when_disabled has no effect with condition_mode \"hide\"")
+		}
+	}
+
+	if item.Condition != "" {
+		expr, err := compileCondition(item.Condition)
+		if err != nil {
+			c.errorf(key+".condition", "invalid condition: %v", err)
+			return
+		}
+		for _, field := range conditionFields(expr) {
+			if _, ok := names[field]; !ok {
+				c.errorf(key+".condition", "condition references unknown field %q", field)
+			}
+		}
+	}
+}
+
+func (c *configChecker) checkSeparator(key string, item *Item) {
+	sep := item.Separator
+	if sep == "" {
+		return
+	}
+	if item.Positional || item.Type == "bool" {
+		c.warnf(key+".separator", "separator is ignored for %s", describeItemKind(item))
+		return
+	}
+	if sep != " " && sep != "none" && strings.ContainsAny(sep, " \t\r\n") {
+		c.errorf(key+".separator", "separator %q must be \" \", \"none\" or contain no whitespace", sep)
+	}
+}
+
+func (c *configChecker) checkPicker(key string, item *Item) {
+	if len(item.Filters) > 0 && item.Picker != "file" && item.Picker != "save" && item.Picker != "files" {
+		c.warnf(key+".filters", "filters is only used with file, files and save pickers")
+	}
+	// 批量输入字段总是多值
+	batchItem := c.batch != nil && c.batch.Item == item.Name
+	if item.Picker == "files" && !item.Multi && !item.Positional && !batchItem {
+		c.warnf(key+".picker", "picker \"files\" keeps only the first file without multi or positional")
+	}
+	if item.StartDir != "" && item.Picker == "" {
+		c.warnf(key+".start_dir", "start_dir is ignored without picker")
+	}
+	if item.Picker == "" {
+		return
+	}
+	if !pickers[item.Picker] {
+		c.errorf(key+".picker", "unknown picker %q", item.Picker)
+	} else if item.Type != "string" {
+		c.warnf(key+".picker", "picker is ignored for type %q", item.Type)
+	}
+}
+
+func (c *configChecker) checkRange(key string, item *Item) {
+	if item.Min == nil && item.Max == nil {
+		return
+	}
+	if item.Type != "number" {
+		c.warnf(key, "min/max is ignored for type %q", item.Type)
+	}
+	min, minOK := toFloat(item.Min)
+	if item.Min != nil && !minOK {
+		c.errorf(key+".min", "min must be a number, got %v", item.Min)
+	}
+	max, maxOK := toFloat(item.Max)
+	if item.Max != nil && !maxOK {
+		c.errorf(key+".max", "max must be a number, got %v", item.Max)
+	}
+	if minOK && maxOK && min > max {
+		c.errorf(key+".min", "min %v is greater than max %v", item.Min, item.Max)
+	}
+}
+
+func (c *configChecker) checkDefault(key string, item *Item) {
+	if item.Default == nil {
+		return
+	}
+	if item.Multi && item.Type != "bool" && item.Type != "multichoice" {
+		// 逐个检查每个默认值
+		single := *item
+		single.Multi = false
+		for _, val := range item.DefaultValues() {
+			single.Default = val
+			c.checkDefault(key, &single)
+		}
+		return
+	}
+	switch item.Type {
+	case "bool":
+		if _, ok := item.Default.(bool); !ok {
+			c.errorf(key+".default", "default must be true or false, got %v", item.Default)
+		}
+	case "number":
+		if _, ok := toFloat(item.Default); !ok {
+			if _, err := strconv.ParseFloat(fmt.Sprintf("%v", item.Default), 64); err != nil {
+				c.errorf(key+".default", "default must be a number, got %v", item.Default)
+			}
+		}
+	case "choice":
+		val := fmt.Sprintf("%v", item.Default)
+		if !slices.Contains(item.Choices, val) {
+			c.errorf(key+".default", "default %q is not among choices", val)
+		}
+	case "multichoice":
+		for _, val := range item.DefaultValues() {
+			if !slices.Contains(item.Choices, val) {
+				c.errorf(key+".default", "default %q is not among choices", val)
+			}
+		}
+	}
+}
+
+func describeItemKind(item *Item) string {
+	if item.Positional {
+		return "positional items"
+	}
+	return fmt.Sprintf("type %q", item.Type)
+}
+
+// 检查 TOML 中未被识别的键
+func (c *configChecker) checkUndecoded(keys []string) {
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		// 数组表中的键会重复出现，带特殊字符的键名带引号
+		k = strings.ReplaceAll(k, `"`, "")
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		found := false
+		for path := range c.lines {
+			if stripIndexes(path) == k {
+				c.warnf(path, "unknown key")
+				found = true
+			}
+		}
+		if !found {
+			c.warnf(k, "unknown key")
+		}
+	}
+}
+
+var indexPattern = regexp.MustCompile(`\[\d+\]`)
+
+func stripIndexes(path string) string {
+	return indexPattern.ReplaceAllString(path, "")
+}
+
+// 记录每个键所在的行号，键路径形如 apps[0].items[2].type
+func indexKeyLines(data string) map[string]int {
+	lines := make(map[string]int)
+	arrays := make(map[string]int)
+	resolve := func(name string) string {
+		parts := strings.Split(name, ".")
+		prefix := ""
+		for i, p := range parts {
+			if i > 0 {
+				prefix += "."
+			}
+			prefix += p
+			if idx, ok := arrays[prefix]; ok {
+				parts[i] = fmt.Sprintf("%s[%d]", p, idx)
+			}
+		}
+		return strings.Join(parts, ".")
+	}
+
+	table := ""
+	for n, raw := range strings.Split(data, "\n") {
+		line := strings.TrimSpace(raw)
+		switch {
+		case line == "" || line[0] == '#':
+			continue
+		case strings.HasPrefix(line, "[["):
+			end := strings.Index(line, "]]")
+			if end < 0 {
+				continue
+			}
+			name := strings.TrimSpace(line[2:end])
+			if idx, ok := arrays[name]; ok {
+				arrays[name] = idx + 1
+			} else {
+				arrays[name] = 0
+			}
+			for k := range arrays {
+				if strings.HasPrefix(k, name+".") {
+					delete(arrays, k)
+				}
+			}
+			table = resolve(name)
+			lines[table] = n + 1
+		case line[0] == '[':
+			end := strings.Index(line, "]")
+			if end < 0 {
+				continue
+			}
+			table = resolve(strings.TrimSpace(line[1:end]))
+			lines[table] = n + 1
+		default:
+			i := strings.Index(line, "=")
+			if i <= 0 {
+				continue
+			}
+			key := strings.Trim(strings.TrimSpace(line[:i]), `"'`)
+			if table != "" {
+				key = table + "." + key
+			}
+			if _, ok := lines[key]; !ok {
+				lines[key] = n + 1
+			}
+		}
+	}
+	return lines
+}
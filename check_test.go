@@ -0,0 +1,763 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigParseError(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "echo
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if !diags.HasErrors() {
+		t.Fatal("HasErrors() = false, want true")
+	}
+	if diags[0].Line != 4 {
+		t.Errorf("Line = %d, want 4", diags[0].Line)
+	}
+	if diags[0].File != path {
+		t.Errorf("File = %q, want %q", diags[0].File, path)
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	_, diags := loadConfig("/nonexistent/config.toml")
+	if !diags.HasErrors() {
+		t.Error("HasErrors() = false, want true")
+	}
+}
+
+func TestCheckConfigErrors(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "mode"
+type = "choice"
+choices = ["a", "b"]
+
+[[apps.items]]
+name = "count"
+type = "integer"
+
+[[apps.items]]
+name = "mode"
+type = "string"
+
+[[apps.items]]
+name = "pattern"
+type = "string"
+validate = "[a-"
+
+[[apps.items]]
+name = "extra"
+type = "string"
+condition = "missing=true"
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	tests := []struct {
+		key  string
+		line int
+		msg  string
+	}{
+		{"apps[0].items[1].type", 13, "unknown type"},
+		{"apps[0].items[2].name", 16, "duplicate name"},
+		{"apps[0].items[3].validate", 22, "invalid regex"},
+		{"apps[0].items[4].condition", 27, "unknown field"},
+	}
+	for _, tt := range tests {
+		d := findDiagnostic(diags, tt.key)
+		if d == nil {
+			t.Errorf("missing diagnostic for %s in:\n%s", tt.key, diags)
+			continue
+		}
+		if d.Severity != SeverityError {
+			t.Errorf("%s: Severity = %q, want %q", tt.key, d.Severity, SeverityError)
+		}
+		if d.Line != tt.line {
+			t.Errorf("%s: Line = %d, want %d", tt.key, d.Line, tt.line)
+		}
+		if !strings.Contains(d.Message, tt.msg) {
+			t.Errorf("%s: Message = %q, want to contain %q", tt.key, d.Message, tt.msg)
+		}
+	}
+}
+
+func TestCheckConfigWarnings(t *testing.T) {
+	toml := `
+[[apps]]
+name = "unused"
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "flag"
+type = "bool"
+choices = ["a"]
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if diags.HasErrors() {
+		t.Fatalf("HasErrors() = true, want false:\n%s", diags)
+	}
+	if d := findDiagnostic(diags, "apps[0].name"); d == nil || d.Line != 3 {
+		t.Errorf("unknown key diagnostic = %v, want line 3", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[0].choices"); d == nil || d.Severity != SeverityWarning {
+		t.Errorf("choices diagnostic = %v, want warning", d)
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	d := Diagnostic{File: "a.toml", Line: 3, Key: "apps[0].command.path", Severity: SeverityError, Message: "command path is required"}
+	want := "a.toml:3: error: apps[0].command.path: command path is required"
+	if d.String() != want {
+		t.Errorf("String() = %q, want %q", d.String(), want)
+	}
+}
+
+func TestIndexKeyLines(t *testing.T) {
+	data := `title = "x"
+
+[[apps]]
+[apps.command]
+path = "a"
+[apps.command.env]
+FOO = "bar"
+
+[[apps.items]]
+name = "one"
+
+[[apps]]
+[[apps.items]]
+name = "two"
+`
+	lines := indexKeyLines(data)
+	tests := map[string]int{
+		"title":                   1,
+		"apps[0]":                 3,
+		"apps[0].command.path":    5,
+		"apps[0].command.env.FOO": 7,
+		"apps[0].items[0].name":   10,
+		"apps[1]":                 12,
+		"apps[1].items[0]":        13,
+		"apps[1].items[0].name":   14,
+	}
+	for key, want := range tests {
+		if lines[key] != want {
+			t.Errorf("lines[%q] = %d, want %d", key, lines[key], want)
+		}
+	}
+}
+
+func findDiagnostic(diags Diagnostics, key string) *Diagnostic {
+	for i := range diags {
+		if diags[i].Key == key {
+			return &diags[i]
+		}
+	}
+	return nil
+}
+
+func TestCheckConfigItemRules(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "sep"
+type = "string"
+separator = " = "
+
+[[apps.items]]
+name = "range"
+type = "number"
+min = 10
+max = 1
+
+[[apps.items]]
+name = "fmt"
+type = "choice"
+choices = ["json", "xml"]
+default = "yaml"
+
+[[apps.items]]
+name = "flag"
+type = "bool"
+default = "yes"
+
+[[apps.items]]
+name = "file"
+type = "string"
+picker = "folder"
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	for _, key := range []string{
+		"apps[0].items[0].separator",
+		"apps[0].items[1].min",
+		"apps[0].items[2].default",
+		"apps[0].items[3].default",
+		"apps[0].items[4].picker",
+	} {
+		d := findDiagnostic(diags, key)
+		if d == nil || d.Severity != SeverityError {
+			t.Errorf("%s: diagnostic = %v, want error", key, d)
+		}
+	}
+}
+
+func TestCheckUndecodedArrayTables(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "a"
+type = "string"
+"x:y" = 1
+
+[[apps.items]]
+name = "b"
+type = "string"
+"x:y" = 2
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+	if len(diags) != 2 {
+		t.Fatalf("len(diags) = %d, want 2:\n%s", len(diags), diags)
+	}
+	if diags[0].Key != "apps[0].items[0].x:y" || diags[1].Key != "apps[0].items[1].x:y" {
+		t.Errorf("diags = \n%s", diags)
+	}
+}
+
+func TestCheckConfigConditionExpression(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "a"
+type = "bool"
+
+[[apps.items]]
+name = "b"
+type = "string"
+condition = "a && missing > 1"
+
+[[apps.items]]
+name = "c"
+type = "string"
+condition = "(a"
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if d := findDiagnostic(diags, "apps[0].items[1].condition"); d == nil || !strings.Contains(d.Message, `"missing"`) {
+		t.Errorf("unknown field diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[2].condition"); d == nil || !strings.Contains(d.Message, "invalid condition") {
+		t.Errorf("parse error diagnostic = %v", d)
+	}
+}
+
+func TestCheckConfigConditionMode(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+condition_mode = "collapse"
+
+[[apps.items]]
+name = "a"
+type = "bool"
+condition_mode = "hide"
+
+[[apps.items]]
+name = "b"
+type = "string"
+condition = "a"
+condition_mode = "remove"
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if d := findDiagnostic(diags, "apps[0].command.condition_mode"); d == nil || d.Severity != SeverityError {
+		t.Errorf("command condition_mode diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[0].condition_mode"); d == nil || d.Severity != SeverityWarning {
+		t.Errorf("condition_mode without condition diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[1].condition_mode"); d == nil || d.Severity != SeverityError {
+		t.Errorf("item condition_mode diagnostic = %v", d)
+	}
+}
+
+func TestCheckConfigEmitWhenDisabled(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "a"
+type = "bool"
+emit_when_disabled = true
+
+[[apps.items]]
+name = "b"
+type = "string"
+condition = "a"
+emit_when_disabled = true
+
+[[apps]]
+[apps.command]
+path = "cmd"
+condition_mode = "hide"
+
+[[apps.items]]
+name = "a"
+type = "bool"
+
+[[apps.items]]
+name = "b"
+type = "string"
+condition = "a"
+emit_when_disabled = true
+
+[[apps.items]]
+name = "c"
+type = "string"
+condition = "a"
+condition_mode = "disable"
+emit_when_disabled = true
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if d := findDiagnostic(diags, "apps[0].items[0].emit_when_disabled"); d == nil || d.Severity != SeverityWarning {
+		t.Errorf("emit_when_disabled without condition diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[1].emit_when_disabled"); d != nil {
+		t.Errorf("unexpected diagnostic: %v", d)
+	}
+	// 继承 app 的 condition_mode = "hide"
+	if d := findDiagnostic(diags, "apps[1].items[1].emit_when_disabled"); d == nil || d.Severity != SeverityWarning {
+		t.Errorf("emit_when_disabled with inherited hide diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[1].items[2].emit_when_disabled"); d != nil {
+		t.Errorf("unexpected diagnostic with item disable: %v", d)
+	}
+}
+
+func TestCheckConfigVisible(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+mode = "visible"
+[apps.command.stdin]
+text = "hello"
+
+[[apps.items]]
+name = "a"
+type = "text"
+as_file = true
+
+[[apps.items]]
+name = "b"
+type = "text"
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	for _, key := range []string{"apps[0].command.stdin", "apps[0].items[0].as_file"} {
+		if d := findDiagnostic(diags, key); d == nil || d.Severity != SeverityError {
+			t.Errorf("%s diagnostic = %v", key, d)
+		}
+	}
+	if d := findDiagnostic(diags, "apps[0].items[1].as_file"); d != nil {
+		t.Errorf("unexpected diagnostic: %v", d)
+	}
+}
+
+func TestCheckConfigText(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "a"
+type = "string"
+rows = 4
+
+[[apps.items]]
+name = "b"
+type = "text"
+as_file = true
+stdin = true
+
+[[apps.items]]
+name = "c"
+type = "text"
+stdin = true
+
+[[apps.items]]
+name = "d"
+type = "text"
+stdin = true
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if d := findDiagnostic(diags, "apps[0].items[0].rows"); d == nil || d.Severity != SeverityWarning {
+		t.Errorf("rows on string diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[1].stdin"); d == nil || !strings.Contains(d.Message, "as_file and stdin") {
+		t.Errorf("as_file with stdin diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[3].stdin"); d == nil || !strings.Contains(d.Message, "items[1]") {
+		t.Errorf("second stdin item diagnostic = %v", d)
+	}
+}
+
+func TestCheckConfigStdin(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+[apps.command.stdin]
+text = "${query}"
+item = "q"
+
+[[apps]]
+[apps.command]
+path = "cmd"
+[apps.command.stdin]
+file = "${missing}"
+
+[[apps.items]]
+name = "body"
+type = "text"
+stdin = true
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if d := findDiagnostic(diags, "apps[0].command.stdin"); d == nil || !strings.Contains(d.Message, "exactly one") || d.Line != 5 {
+		t.Errorf("stdin with two sources diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[1].command.stdin"); d == nil || !strings.Contains(d.Message, `"missing"`) {
+		t.Errorf("stdin unknown field diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[1].items[0].stdin"); d == nil || !strings.Contains(d.Message, "conflicts") {
+		t.Errorf("item stdin conflict diagnostic = %v", d)
+	}
+}
+
+func TestCheckConfigTemplate(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "width"
+type = "number"
+
+[[apps.items]]
+name = "scale"
+type = "bool"
+template = ["-vf", "scale=${width}:${height}"]
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	d := findDiagnostic(diags, "apps[0].items[1].template[1]")
+	if d == nil || !strings.Contains(d.Message, `"height"`) || d.Line != 13 {
+		t.Errorf("template diagnostic = %v", d)
+	}
+}
+
+func TestCheckConfigPosition(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "a"
+type = "string"
+position = "first"
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if d := findDiagnostic(diags, "apps[0].items[0].position"); d == nil || d.Severity != SeverityError {
+		t.Errorf("position diagnostic = %v", d)
+	}
+}
+
+func TestCheckConfigBoolValues(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "a"
+type = "string"
+true_value = "1"
+
+[[apps.items]]
+name = "b"
+type = "bool"
+on = ["--b", "${missing}"]
+true_value = "yes"
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if d := findDiagnostic(diags, "apps[0].items[0].true_value"); d == nil || d.Severity != SeverityWarning {
+		t.Errorf("true_value on string diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[1].true_value"); d == nil || !strings.Contains(d.Message, "on is set") {
+		t.Errorf("true_value with on diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[1].on[1]"); d == nil || d.Severity != SeverityError {
+		t.Errorf("on unknown field diagnostic = %v", d)
+	}
+}
+
+func TestCheckConfigMulti(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "a"
+type = "string"
+join = ","
+
+[[apps.items]]
+name = "b"
+type = "string"
+multi = true
+min_count = 3
+max_count = 2
+
+[[apps.items]]
+name = "c"
+type = "choice"
+choices = ["x", "y"]
+multi = true
+default = ["x", "z"]
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if d := findDiagnostic(diags, "apps[0].items[0].join"); d == nil || d.Severity != SeverityWarning {
+		t.Errorf("join without multi diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[1].max_count"); d == nil || d.Severity != SeverityError {
+		t.Errorf("max_count diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[2].default"); d == nil || d.Severity != SeverityError || !strings.Contains(d.Message, `"z"`) {
+		t.Errorf("multi default diagnostic = %v", d)
+	}
+}
+
+func TestCheckConfigMultiChoice(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "a"
+type = "multichoice"
+
+[[apps.items]]
+name = "b"
+type = "multichoice"
+choices = ["x", "y"]
+default = ["x", "z"]
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if d := findDiagnostic(diags, "apps[0].items[0].choices"); d == nil || d.Severity != SeverityError {
+		t.Errorf("choices diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[1].default"); d == nil || !strings.Contains(d.Message, `"z"`) {
+		t.Errorf("default diagnostic = %v", d)
+	}
+}
+
+func TestCheckConfigChoiceStyle(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "a"
+type = "choice"
+choices = ["x"]
+style = "tabs"
+
+[[apps.items]]
+name = "b"
+type = "string"
+style = "radio"
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if d := findDiagnostic(diags, "apps[0].items[0].style"); d == nil || d.Severity != SeverityError {
+		t.Errorf("unknown style diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[1].style"); d == nil || d.Severity != SeverityWarning {
+		t.Errorf("style on string diagnostic = %v", d)
+	}
+}
+
+func TestCheckConfigPicker(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+
+[[apps.items]]
+name = "a"
+type = "string"
+picker = "directory"
+filters = ["*.mp4"]
+
+[[apps.items]]
+name = "b"
+type = "string"
+picker = "save"
+suggest = "${a:stem}.mp4 ${missing:dir}"
+
+[[apps.items]]
+name = "c"
+type = "string"
+picker = "files"
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	if d := findDiagnostic(diags, "apps[0].items[0].filters"); d == nil || d.Severity != SeverityWarning {
+		t.Errorf("filters diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[1].suggest"); d == nil || !strings.Contains(d.Message, "missing:dir") {
+		t.Errorf("suggest diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[1].picker"); d != nil {
+		t.Errorf("save picker diagnostic = %v", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].items[2].picker"); d == nil || d.Severity != SeverityWarning {
+		t.Errorf("files picker without multi diagnostic = %v", d)
+	}
+}
+
+func TestCheckConfigBatch(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+[apps.batch]
+item = "n"
+glob = "[*"
+parallel = -1
+
+[[apps.items]]
+name = "n"
+type = "number"
+
+[[apps.items]]
+name = "out"
+type = "string"
+template = ["${input:stem}.mp4", "${input_dir}"]
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	for _, key := range []string{"apps[0].batch.item", "apps[0].batch.glob", "apps[0].batch.parallel"} {
+		if d := findDiagnostic(diags, key); d == nil || d.Severity != SeverityError {
+			t.Errorf("%s diagnostic = %v", key, d)
+		}
+	}
+	// 批量执行时可以引用 ${input:stem} 和 ${input_dir}
+	for _, key := range []string{"apps[0].items[1].template[0]", "apps[0].items[1].template[1]"} {
+		if d := findDiagnostic(diags, key); d != nil {
+			t.Errorf("%s diagnostic = %v", key, d)
+		}
+	}
+}
+
+func TestCheckConfigMaxJobs(t *testing.T) {
+	toml := `
+max_jobs = -1
+
+[[apps]]
+[apps.command]
+path = "cmd"
+max_jobs = -2
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	for _, key := range []string{"max_jobs", "apps[0].command.max_jobs"} {
+		if d := findDiagnostic(diags, key); d == nil || d.Severity != SeverityError {
+			t.Errorf("%s diagnostic = %v", key, d)
+		}
+	}
+}
+
+func TestCheckConfigStop(t *testing.T) {
+	toml := `
+[[apps]]
+[apps.command]
+path = "cmd"
+stop_signal = "SIGFOO"
+stop_timeout = "5"
+
+[[apps]]
+[apps.command]
+path = "cmd"
+stop_signal = "int"
+stop_timeout = "500ms"
+`
+	path := writeTempFile(t, toml)
+	_, diags := loadConfig(path)
+
+	for _, key := range []string{"apps[0].command.stop_signal", "apps[0].command.stop_timeout"} {
+		if d := findDiagnostic(diags, key); d == nil || d.Severity != SeverityError {
+			t.Errorf("%s diagnostic = %v", key, d)
+		}
+	}
+	for _, key := range []string{"apps[1].command.stop_signal", "apps[1].command.stop_timeout"} {
+		if d := findDiagnostic(diags, key); d != nil {
+			t.Errorf("%s diagnostic = %v", key, d)
+		}
+	}
+}
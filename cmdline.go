@@ -0,0 +1,365 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 按 shell 规则拆分命令行，支持单双引号和反斜杠转义
+func splitCommandLine(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' {
+				escaped = true
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// 参数匹配规则
+type argMatcher struct {
+	item *Item
+	flag string
+}
+
+// 将参数反向解析为各字段的值，返回无法对应的参数
+func parseArgs(app *App, argv []string) (map[string][]string, []string) {
+	argv = stripFixedArgs(argv, app.Command.Args)
+	argv = stripFixedArgs(argv, app.Command.ArgsAfter)
+
+	var matchers []argMatcher
+	var positionals []*Item
+	var templated []*Item
+	for i := range app.Items {
+		item := &app.Items[i]
+		if item.IsLabel() || item.Type == "text" && item.Stdin {
+			continue
+		}
+		if item.Template != nil {
+			templated = append(templated, item)
+			continue
+		}
+		if item.Positional {
+			positionals = append(positionals, item)
+			continue
+		}
+		prefix := "--"
+		if item.Short {
+			prefix = "-"
+		}
+		matchers = append(matchers, argMatcher{item: item, flag: prefix + item.Name})
+	}
+	// 长参数优先，避免 -c 抢先匹配 -cv 这类参数
+	sort.SliceStable(matchers, func(i, j int) bool { return len(matchers[i].flag) > len(matchers[j].flag) })
+
+	values := make(map[string][]string)
+	var unmatched []string
+	set := func(item *Item, val string) {
+		// 临时文件路径不能还原为文本内容
+		if item.Indirect() {
+			return
+		}
+		vals := []string{val}
+		if item.MultiValued() && item.Join != "" {
+			vals = strings.Split(val, item.Join)
+		}
+		for _, v := range vals {
+			if (item.Type == "choice" || item.Type == "multichoice") && !slices.Contains(item.Choices, v) {
+				unmatched = append(unmatched, val)
+				return
+			}
+		}
+		if item.MultiValued() {
+			values[item.Name] = append(values[item.Name], vals...)
+		} else {
+			values[item.Name] = vals
+		}
+	}
+
+	endOfOptions := false
+	for i := 0; i < len(argv); i++ {
+		tok := argv[i]
+		if tok == "--" && !endOfOptions {
+			endOfOptions = true
+			continue
+		}
+		matched := false
+		if !endOfOptions {
+			for _, item := range templated {
+				val, n, ok := matchTemplate(item, argv[i:])
+				if !ok {
+					continue
+				}
+				set(item, val)
+				i += n - 1
+				matched = true
+				break
+			}
+		}
+		if !endOfOptions && !matched {
+			for _, m := range matchers {
+				val, consumed, ok := matchArg(m, tok, argv[i+1:])
+				if !ok {
+					continue
+				}
+				set(m.item, val)
+				i += consumed
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if (endOfOptions || !strings.HasPrefix(tok, "-")) && len(positionals) > 0 {
+			set(positionals[0], tok)
+			// 多值位置参数接收剩余的全部位置参数
+			if !positionals[0].MultiValued() {
+				positionals = positionals[1:]
+			}
+			continue
+		}
+		unmatched = append(unmatched, tok)
+	}
+	return values, unmatched
+}
+
+// 匹配单个参数，返回值和额外消耗的参数个数
+func matchArg(m argMatcher, tok string, rest []string) (string, int, bool) {
+	if m.item.Type == "bool" {
+		return matchBool(m, tok, rest)
+	}
+	return matchValue(m, tok, rest)
+}
+
+// 匹配 bool 参数，按 on/off 模板或 true_value/false_value 判断状态
+func matchBool(m argMatcher, tok string, rest []string) (string, int, bool) {
+	item := m.item
+	argv := append([]string{tok}, rest...)
+	if item.On != nil && matchLiteral(item.On, argv) {
+		return "true", len(item.On) - 1, true
+	}
+	if item.Off != nil && matchLiteral(item.Off, argv) {
+		return "false", len(item.Off) - 1, true
+	}
+	if item.On != nil || item.Template != nil {
+		return "", 0, false
+	}
+	if item.TrueValue == "" && tok == m.flag {
+		return "true", 0, true
+	}
+	if item.TrueValue == "" && item.FalseValue == "" {
+		return "", 0, false
+	}
+	val, n, ok := matchValue(m, tok, rest)
+	switch {
+	case !ok:
+		return "", 0, false
+	case item.TrueValue != "" && val == item.TrueValue:
+		return "true", n, true
+	case item.FalseValue != "" && val == item.FalseValue:
+		return "false", n, true
+	}
+	return "", 0, false
+}
+
+// 参数与不含占位符的模板完全一致
+func matchLiteral(tmpl, argv []string) bool {
+	if len(tmpl) == 0 || len(argv) < len(tmpl) {
+		return false
+	}
+	for i, tok := range tmpl {
+		if len(templateNames(tok)) > 0 || expandTemplate(tok, nil) != argv[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 匹配带值的参数
+func matchValue(m argMatcher, tok string, rest []string) (string, int, bool) {
+	item := m.item
+	if tok == m.flag {
+		// 分隔符为空格时值在下一个参数中，其他分隔符也兼容这种写法
+		if len(rest) == 0 {
+			return "", 0, false
+		}
+		return rest[0], 1, true
+	}
+	sep := item.Separator
+	switch sep {
+	case "", " ":
+		sep = "="
+	case "none":
+		sep = ""
+	}
+	if strings.HasPrefix(tok, m.flag+sep) && len(tok) > len(m.flag+sep) {
+		return tok[len(m.flag+sep):], 0, true
+	}
+	if sep != "=" && strings.HasPrefix(tok, m.flag+"=") {
+		return tok[len(m.flag)+1:], 0, true
+	}
+	return "", 0, false
+}
+
+// 匹配模板参数，返回值和消耗的参数个数。
+// 只支持引用 ${value} 的模板，且至少包含一段固定文本；bool 模板匹配时值为 true
+func matchTemplate(item *Item, argv []string) (string, int, bool) {
+	tmpl := item.Template
+	if len(argv) < len(tmpl) {
+		return "", 0, false
+	}
+	val, found, literal := "", false, false
+	for i, tok := range tmpl {
+		names := templateNames(tok)
+		if len(names) == 0 {
+			if expandTemplate(tok, nil) != argv[i] {
+				return "", 0, false
+			}
+			literal = literal || tok != ""
+			continue
+		}
+		if len(names) != 1 || names[0] != "value" || found {
+			return "", 0, false
+		}
+		idx := strings.Index(tok, "${value}")
+		if idx < 0 || strings.Count(tok, "${value}") != 1 {
+			return "", 0, false
+		}
+		prefix, suffix := tok[:idx], tok[idx+len("${value}"):]
+		arg := argv[i]
+		if len(arg) < len(prefix)+len(suffix) || !strings.HasPrefix(arg, prefix) || !strings.HasSuffix(arg, suffix) {
+			return "", 0, false
+		}
+		val = arg[len(prefix) : len(arg)-len(suffix)]
+		found = true
+		literal = literal || prefix != "" || suffix != ""
+	}
+	if !literal {
+		return "", 0, false
+	}
+	if !found {
+		if item.Type != "bool" {
+			return "", 0, false
+		}
+		val = "true"
+	}
+	return val, len(tmpl), true
+}
+
+// 去掉命令中的固定参数
+func stripFixedArgs(argv, fixed []string) []string {
+	if len(fixed) == 0 {
+		return argv
+	}
+	if len(argv) >= len(fixed) && slices.Equal(argv[:len(fixed)], fixed) {
+		return argv[len(fixed):]
+	}
+	if len(argv) >= len(fixed) && slices.Equal(argv[len(argv)-len(fixed):], fixed) {
+		return argv[:len(argv)-len(fixed)]
+	}
+	argv = append([]string{}, argv...)
+	for _, f := range fixed {
+		if i := slices.Index(argv, f); i >= 0 {
+			argv = slices.Delete(argv, i, i+1)
+		}
+	}
+	return argv
+}
+
+// 从命令行填充表单，返回无法对应的参数
+func (u *AppUI) LoadCommandLine(line string) ([]string, error) {
+	argv, err := splitCommandLine(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(argv) > 0 && (argv[0] == u.app.Command.Path || filepath.Base(argv[0]) == filepath.Base(u.app.Command.Path)) {
+		argv = argv[1:]
+	}
+	values, unmatched := parseArgs(u.app, argv)
+	u.applyValues(values)
+	return unmatched, nil
+}
+
+// 设置所有字段的值，未给出的字段清空
+func (u *AppUI) applyValues(values map[string][]string) {
+	for i := range u.app.Items {
+		item := &u.app.Items[i]
+		// 临时文件和标准输入的内容不在命令行中，保留当前值
+		if item.IsLabel() || item.Indirect() {
+			continue
+		}
+		u.setWidgetValues(item, u.widgets[item.Name], values[item.Name])
+	}
+}
+
+func (u *AppUI) pasteCommand() {
+	entry := widget.NewMultiLineEntry()
+	entry.Wrapping = fyne.TextWrapWord
+	entry.SetText(u.window.Clipboard().Content())
+	d := dialog.NewCustomConfirm("Paste Command", "Load", "Cancel", entry, func(ok bool) {
+		if !ok {
+			return
+		}
+		unmatched, err := u.LoadCommandLine(entry.Text)
+		if err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		if len(unmatched) > 0 {
+			dialog.ShowInformation("Unmatched Arguments", fmt.Sprintf("These arguments could not be mapped:\n%s", strings.Join(unmatched, "\n")), u.window)
+		}
+	}, u.window)
+	size := u.window.Canvas().Size()
+	d.Resize(fyne.NewSize(size.Width*2/3, size.Height*2/3))
+	d.Show()
+}
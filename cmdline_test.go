@@ -0,0 +1,300 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"a b  c", []string{"a", "b", "c"}},
+		{`curl -H 'Content-Type: application/json' -d "{\"a\":1}"`, []string{"curl", "-H", "Content-Type: application/json", "-d", `{"a":1}`}},
+		{`'--msg=hello world'`, []string{"--msg=hello world"}},
+		{`a\ b ''`, []string{"a b", ""}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.input)
+		if err != nil {
+			t.Errorf("splitCommandLine(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := splitCommandLine(`echo "unterminated`); err == nil {
+		t.Error("splitCommandLine() with unterminated quote should fail")
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "ffmpeg", Args: []string{"-y"}},
+		Items: []Item{
+			{Name: "i", Type: "string", Short: true, Separator: " "},
+			{Name: "c:v", Type: "choice", Short: true, Separator: " ", Choices: []string{"libx264", "copy"}},
+			{Name: "O", Type: "string", Short: true, Separator: "none"},
+			{Name: "level", Type: "number"},
+			{Name: "map", Type: "string", Separator: ":"},
+			{Name: "v", Type: "bool", Short: true},
+			{Name: "H", Type: "string", Short: true, Multi: true, Separator: " "},
+			{Name: "output", Type: "string", Positional: true},
+		},
+	}
+	argv := []string{"-y", "-i", "in.mp4", "-c:v", "copy", "-O2", "--level=3", "--map:0", "-v", "-H", "a", "-H", "b", "out.mp4", "--bogus"}
+	values, unmatched := parseArgs(app, argv)
+
+	want := map[string][]string{
+		"i":      {"in.mp4"},
+		"c:v":    {"copy"},
+		"O":      {"2"},
+		"level":  {"3"},
+		"map":    {"0"},
+		"v":      {"true"},
+		"H":      {"a", "b"},
+		"output": {"out.mp4"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+	if !reflect.DeepEqual(unmatched, []string{"--bogus"}) {
+		t.Errorf("unmatched = %v, want [--bogus]", unmatched)
+	}
+}
+
+func TestParseArgsLenientSeparator(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "opt", Type: "string"},
+			{Name: "o", Type: "string", Short: true, Separator: " "},
+		},
+	}
+	// = 分隔的参数也接受空格形式，反之亦然
+	values, unmatched := parseArgs(app, []string{"--opt", "a", "-o=b"})
+	want := map[string][]string{"opt": {"a"}, "o": {"b"}}
+	if !reflect.DeepEqual(values, want) || len(unmatched) != 0 {
+		t.Errorf("values = %v, unmatched = %v, want %v", values, unmatched, want)
+	}
+}
+
+func TestParseArgsTemplate(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "helm"},
+		Items: []Item{
+			{Name: "image", Type: "string", Template: []string{"--set=image.tag=${value}"}},
+			{Name: "define", Type: "string", Multi: true, Template: []string{"-D", "${value}"}},
+			{Name: "debug", Type: "bool", Template: []string{"--log-level", "debug"}},
+			{Name: "scale", Type: "bool", Template: []string{"-vf", "scale=${width}:${height}"}},
+			{Name: "width", Type: "number"},
+		},
+	}
+	argv := []string{"--set=image.tag=1.2", "-D", "a=1", "--log-level", "debug", "-D", "b=2", "-vf", "scale=1:2", "--width=1"}
+	values, unmatched := parseArgs(app, argv)
+
+	want := map[string][]string{
+		"image":  {"1.2"},
+		"define": {"a=1", "b=2"},
+		"debug":  {"true"},
+		"width":  {"1"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+	// 引用其他字段的模板无法反向解析
+	if !reflect.DeepEqual(unmatched, []string{"-vf", "scale=1:2"}) {
+		t.Errorf("unmatched = %v", unmatched)
+	}
+}
+
+func TestParseArgsEndOfOptions(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "f", Type: "bool", Short: true},
+			{Name: "file", Type: "string", Positional: true},
+		},
+	}
+	values, unmatched := parseArgs(app, []string{"--", "-f"})
+	if !reflect.DeepEqual(values, map[string][]string{"file": {"-f"}}) || len(unmatched) != 0 {
+		t.Errorf("values = %v, unmatched = %v", values, unmatched)
+	}
+}
+
+func TestParseArgsArgsAfter(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "ffmpeg", Args: []string{"-y"}, ArgsAfter: []string{"-f", "mp4"}},
+		Items: []Item{
+			{Name: "hide_banner", Type: "bool", Short: true, Position: "before"},
+			{Name: "output", Type: "string", Positional: true},
+		},
+	}
+	values, unmatched := parseArgs(app, []string{"-hide_banner", "-y", "--", "-out.mp4", "-f", "mp4"})
+	want := map[string][]string{"hide_banner": {"true"}, "output": {"-out.mp4"}}
+	if !reflect.DeepEqual(values, want) || len(unmatched) != 0 {
+		t.Errorf("values = %v, unmatched = %v, want %v", values, unmatched, want)
+	}
+}
+
+func TestParseArgsBoolValues(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "color", Type: "bool", Off: []string{"--no-color"}},
+			{Name: "cache", Type: "bool", TrueValue: "yes", FalseValue: "no"},
+			{Name: "v", Type: "bool", Short: true, Separator: " ", TrueValue: "1", FalseValue: "0"},
+			{Name: "mode", Type: "bool", On: []string{"--fast"}, Off: []string{"--slow"}},
+		},
+	}
+	values, unmatched := parseArgs(app, []string{"--no-color", "--cache=yes", "-v", "0", "--slow", "--mode"})
+	want := map[string][]string{
+		"color": {"false"},
+		"cache": {"true"},
+		"v":     {"false"},
+		"mode":  {"false"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+	// 设置了 on 时 --mode 不再对应该字段
+	if !reflect.DeepEqual(unmatched, []string{"--mode"}) {
+		t.Errorf("unmatched = %v", unmatched)
+	}
+}
+
+func TestParseArgsMulti(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "mode", Type: "choice", Multi: true, Join: ",", Choices: []string{"a", "b"}},
+			{Name: "f", Type: "multichoice", Choices: []string{"x", "y"}},
+			{Name: "out", Type: "string", Positional: true},
+			{Name: "in", Type: "string", Positional: true, Multi: true},
+		},
+	}
+	values, unmatched := parseArgs(app, []string{"--mode=b,a", "--f=y", "out.txt", "--f=x", "x", "y"})
+	want := map[string][]string{
+		"mode": {"b", "a"},
+		"f":    {"y", "x"},
+		"out":  {"out.txt"},
+		"in":   {"x", "y"},
+	}
+	if !reflect.DeepEqual(values, want) || len(unmatched) != 0 {
+		t.Errorf("values = %v, unmatched = %v, want %v", values, unmatched, want)
+	}
+}
+
+func TestParseArgsInvalidChoice(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items:   []Item{{Name: "format", Type: "choice", Choices: []string{"json", "xml"}}},
+	}
+	values, unmatched := parseArgs(app, []string{"--format=yaml"})
+	if len(values) != 0 || !reflect.DeepEqual(unmatched, []string{"yaml"}) {
+		t.Errorf("values = %v, unmatched = %v", values, unmatched)
+	}
+}
+
+func TestLoadCommandLine(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "/usr/bin/curl"},
+		Items: []Item{
+			{Name: "url", Type: "string", Positional: true},
+			{Name: "X", Type: "choice", Short: true, Separator: " ", Choices: []string{"GET", "POST"}},
+			{Name: "H", Type: "string", Short: true, Multi: true, Separator: " "},
+			{Name: "o", Type: "string", Short: true, Separator: " ", Picker: "file"},
+			{Name: "L", Type: "bool", Short: true},
+			{Name: "k", Type: "bool", Short: true, Default: true},
+			{Name: "max-time", Type: "number", Default: int64(30)},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	unmatched, err := ui.LoadCommandLine(`curl -X POST -H 'Accept: */*' -H "X-A: 1" -o out.json -L https://example.com --compressed`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(unmatched, []string{"--compressed"}) {
+		t.Errorf("unmatched = %v, want [--compressed]", unmatched)
+	}
+
+	// 未出现的字段应被清空，包括有默认值的
+	args := ui.BuildArgs()
+	want := []string{"https://example.com", "-X", "POST", "-H", "Accept: */*", "-H", "X-A: 1", "-o", "out.json", "-L"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+	if ui.widgets["k"].(*widget.Check).Checked {
+		t.Error("k should be unchecked")
+	}
+}
+
+func TestLoadCommandLineRoundTrip(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd", Args: []string{"sub"}},
+		Items: []Item{
+			{Name: "flag", Type: "bool"},
+			{Name: "opt", Type: "string"},
+			{Name: "mode", Type: "choice", Choices: []string{"a", "b"}},
+			{Name: "tag", Type: "string", Multi: true},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	ui.widgets["flag"].(*widget.Check).SetChecked(true)
+	setEntryText(ui.widgets["opt"], "hello world")
+	setSelected(ui.widgets["mode"], "b")
+	mw := ui.widgets["tag"].(*multiWidget)
+	setEntryText(mw.rows[0], "x")
+	mw.addRow()
+	setEntryText(mw.rows[1], "y")
+	want := ui.BuildArgs()
+	line := ui.buildCommandLine()
+
+	other := NewAppUI(app, test.NewWindow(nil))
+	other.Build()
+	unmatched, err := other.LoadCommandLine(line)
+	if err != nil || len(unmatched) != 0 {
+		t.Fatalf("LoadCommandLine(%q) = %v, %v", line, unmatched, err)
+	}
+	if got := other.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestMultiWidgetSetValues(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items:   []Item{{Name: "tags", Type: "string", Multi: true}},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	mw := ui.widgets["tags"].(*multiWidget)
+	mw.SetValues([]string{"a", "b"})
+	if !reflect.DeepEqual(mw.Values(), []string{"a", "b"}) {
+		t.Errorf("Values() = %v, want [a b]", mw.Values())
+	}
+	// + 按钮保持在最后
+	if last := mw.vbox.Objects[len(mw.vbox.Objects)-1]; last != mw.addBtn {
+		t.Error("add button should stay last")
+	}
+
+	mw.SetValues(nil)
+	if len(mw.rows) != 1 || len(mw.Values()) != 0 {
+		t.Errorf("rows = %d, Values() = %v, want one empty row", len(mw.rows), mw.Values())
+	}
+}
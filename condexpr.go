@@ -0,0 +1,401 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 条件表达式
+//
+//	expr    := and ('||' and)*
+//	and     := unary ('&&' unary)*
+//	unary   := '!' unary | '(' expr ')' | field [cmp]
+//	cmp     := ('=' | '==' | '!=' | '<' | '<=' | '>' | '>=') value
+//	         | ('~' | '!~') value
+//	         | 'in' '[' value (',' value)* ']'
+//
+// 只有字段名时判断字段是否非空，值可以加单引号或双引号。
+type condExpr interface {
+	eval(get condGetter) bool
+	fields() []string
+}
+
+// 条件求值时读取字段
+type condGetter interface {
+	// 用于比较的值，多值字段每个值一项
+	values(field string) []string
+	// 只有字段名时判断字段是否有值
+	isSet(field string) bool
+}
+
+// 单值字段的 condGetter，按值非空判断 isSet
+type condValues func(field string) string
+
+func (f condValues) values(field string) []string { return []string{f(field)} }
+func (f condValues) isSet(field string) bool      { return f(field) != "" }
+
+// 任一值满足 match 即为真，没有值时按空字符串比较
+func anyValue(get condGetter, field string, match func(string) bool) bool {
+	vals := get.values(field)
+	if len(vals) == 0 {
+		return match("")
+	}
+	return slices.ContainsFunc(vals, match)
+}
+
+type condOr struct{ l, r condExpr }
+type condAnd struct{ l, r condExpr }
+type condNot struct{ x condExpr }
+
+// 字段非空
+type condNonEmpty struct{ field string }
+
+type condCompare struct {
+	field string
+	op    string
+	value string
+}
+
+type condIn struct {
+	field  string
+	values []string
+}
+
+type condMatch struct {
+	field  string
+	re     *regexp.Regexp
+	negate bool
+}
+
+func (c condOr) eval(get condGetter) bool  { return c.l.eval(get) || c.r.eval(get) }
+func (c condAnd) eval(get condGetter) bool { return c.l.eval(get) && c.r.eval(get) }
+func (c condNot) eval(get condGetter) bool { return !c.x.eval(get) }
+func (c condNonEmpty) eval(get condGetter) bool {
+	return get.isSet(c.field)
+}
+
+// 多值字段: = 和大小比较在任一值满足时为真，!= 在所有值都不相等时为真
+func (c condCompare) eval(get condGetter) bool {
+	if c.op == "!=" {
+		return !condCompare{c.field, "=", c.value}.eval(get)
+	}
+	return anyValue(get, c.field, func(actual string) bool { return compareValue(actual, c.op, c.value) })
+}
+
+func compareValue(actual, op, value string) bool {
+	a, aerr := strconv.ParseFloat(actual, 64)
+	b, berr := strconv.ParseFloat(value, 64)
+	numeric := aerr == nil && berr == nil
+	if op == "=" || op == "==" {
+		return actual == value || (numeric && a == b)
+	}
+	// 大小比较只对数字有效
+	if !numeric {
+		return false
+	}
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+func (c condIn) eval(get condGetter) bool {
+	return anyValue(get, c.field, func(v string) bool { return slices.Contains(c.values, v) })
+}
+
+// !~ 在所有值都不匹配时为真
+func (c condMatch) eval(get condGetter) bool {
+	return anyValue(get, c.field, c.re.MatchString) != c.negate
+}
+
+func (c condOr) fields() []string       { return append(c.l.fields(), c.r.fields()...) }
+func (c condAnd) fields() []string      { return append(c.l.fields(), c.r.fields()...) }
+func (c condNot) fields() []string      { return c.x.fields() }
+func (c condNonEmpty) fields() []string { return []string{c.field} }
+func (c condCompare) fields() []string  { return []string{c.field} }
+func (c condIn) fields() []string       { return []string{c.field} }
+func (c condMatch) fields() []string    { return []string{c.field} }
+
+// 表达式中引用的字段，去重并保持顺序
+func conditionFields(e condExpr) []string {
+	var names []string
+	for _, f := range e.fields() {
+		if !slices.Contains(names, f) {
+			names = append(names, f)
+		}
+	}
+	return names
+}
+
+type condToken struct {
+	kind string // word, string, op
+	text string
+	pos  int
+}
+
+const condOperatorChars = "=!<>~&|()[],'\""
+
+func lexCondition(s string) ([]condToken, error) {
+	var toks []condToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at %d", i+1)
+			}
+			toks = append(toks, condToken{"string", s[i+1 : i+1+end], i})
+			i += end + 2
+		case strings.HasPrefix(s[i:], "&&"), strings.HasPrefix(s[i:], "||"),
+			strings.HasPrefix(s[i:], "=="), strings.HasPrefix(s[i:], "!="),
+			strings.HasPrefix(s[i:], "<="), strings.HasPrefix(s[i:], ">="),
+			strings.HasPrefix(s[i:], "!~"):
+			toks = append(toks, condToken{"op", s[i : i+2], i})
+			i += 2
+		case strings.IndexByte("=!<>~()[],", c) >= 0:
+			toks = append(toks, condToken{"op", string(c), i})
+			i++
+		case c == '&' || c == '|':
+			return nil, fmt.Errorf("unexpected %q at %d, use && or ||", c, i+1)
+		default:
+			start := i
+			for i < len(s) && s[i] != ' ' && s[i] != '\t' && strings.IndexByte(condOperatorChars, s[i]) < 0 {
+				i++
+			}
+			toks = append(toks, condToken{"word", s[start:i], start})
+		}
+	}
+	return toks, nil
+}
+
+type condParser struct {
+	src  string
+	toks []condToken
+	pos  int
+}
+
+// 编译条件表达式，无法解析时按旧写法 field=value 处理
+func compileCondition(s string) (condExpr, error) {
+	e, err := parseConditionExpr(s)
+	if err != nil {
+		if legacy, ok := legacyCondition(s); ok {
+			return legacy, nil
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+// 旧写法: 在第一个 != 或 = 处分开，其后直到行尾都是值，可以包含空格等字符。
+// 值中有 && 或 || 时视为新写法的错误
+func legacyCondition(s string) (condExpr, bool) {
+	op := "!="
+	i := strings.Index(s, op)
+	if i <= 0 {
+		op = "="
+		i = strings.Index(s, op)
+	}
+	if i <= 0 {
+		return nil, false
+	}
+	field, value := s[:i], s[i+len(op):]
+	if strings.ContainsAny(field, condOperatorChars+" \t") ||
+		strings.Contains(value, "&&") || strings.Contains(value, "||") {
+		return nil, false
+	}
+	return condCompare{field, op, value}, true
+}
+
+func parseConditionExpr(s string) (condExpr, error) {
+	toks, err := lexCondition(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	p := &condParser{src: s, toks: toks}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t != nil {
+		return nil, p.unexpected(t)
+	}
+	return e, nil
+}
+
+func (p *condParser) peek() *condToken {
+	if p.pos < len(p.toks) {
+		return &p.toks[p.pos]
+	}
+	return nil
+}
+
+func (p *condParser) next() *condToken {
+	t := p.peek()
+	if t != nil {
+		p.pos++
+	}
+	return t
+}
+
+func (p *condParser) isOp(text string) bool {
+	t := p.peek()
+	return t != nil && t.kind == "op" && t.text == text
+}
+
+func (p *condParser) unexpected(t *condToken) error {
+	if t == nil {
+		return fmt.Errorf("unexpected end of condition")
+	}
+	return fmt.Errorf("unexpected %q at %d", t.text, t.pos+1)
+}
+
+func (p *condParser) parseOr() (condExpr, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.next()
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = condOr{l, r}
+	}
+	return l, nil
+}
+
+func (p *condParser) parseAnd() (condExpr, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.next()
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = condAnd{l, r}
+	}
+	return l, nil
+}
+
+func (p *condParser) parseUnary() (condExpr, error) {
+	if p.isOp("!") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return condNot{x}, nil
+	}
+	if p.isOp("(") {
+		p.next()
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isOp(")") {
+			return nil, p.unexpected(p.peek())
+		}
+		p.next()
+		return e, nil
+	}
+	t := p.next()
+	if t == nil || t.kind != "word" {
+		return nil, p.unexpected(t)
+	}
+	return p.parseComparison(t.text)
+}
+
+func (p *condParser) parseComparison(field string) (condExpr, error) {
+	t := p.peek()
+	if t == nil {
+		return condNonEmpty{field}, nil
+	}
+	if t.kind == "word" && t.text == "in" {
+		p.next()
+		return p.parseIn(field)
+	}
+	if t.kind != "op" {
+		return condNonEmpty{field}, nil
+	}
+	switch t.text {
+	case "=", "==", "!=":
+		p.next()
+		// 兼容 field!= 这种省略空值的写法
+		return condCompare{field, t.text, p.parseValue()}, nil
+	case "<", "<=", ">", ">=":
+		p.next()
+		v := p.peek()
+		if v == nil || (v.kind != "word" && v.kind != "string") {
+			return nil, p.unexpected(v)
+		}
+		p.next()
+		return condCompare{field, t.text, v.text}, nil
+	case "~", "!~":
+		p.next()
+		v := p.peek()
+		if v == nil || (v.kind != "word" && v.kind != "string") {
+			return nil, p.unexpected(v)
+		}
+		p.next()
+		re, err := regexp.Compile(v.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %v", v.text, err)
+		}
+		return condMatch{field, re, t.text == "!~"}, nil
+	}
+	return condNonEmpty{field}, nil
+}
+
+// 读取一个值，后面没有值时返回空字符串
+func (p *condParser) parseValue() string {
+	t := p.peek()
+	if t != nil && (t.kind == "word" || t.kind == "string") {
+		p.next()
+		return t.text
+	}
+	return ""
+}
+
+func (p *condParser) parseIn(field string) (condExpr, error) {
+	if !p.isOp("[") {
+		return nil, p.unexpected(p.peek())
+	}
+	p.next()
+	var values []string
+	for {
+		t := p.next()
+		if t == nil || (t.kind != "word" && t.kind != "string") {
+			return nil, p.unexpected(t)
+		}
+		values = append(values, t.text)
+		if p.isOp(",") {
+			p.next()
+			continue
+		}
+		if p.isOp("]") {
+			p.next()
+			return condIn{field, values}, nil
+		}
+		return nil, p.unexpected(p.peek())
+	}
+}
@@ -1,32 +1,204 @@
 package main
 
 import (
+	"reflect"
 	"testing"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/test"
 	"fyne.io/fyne/v2/widget"
 )
 
-func TestParseCondition(t *testing.T) {
+func TestCompileConditionSimple(t *testing.T) {
 	tests := []struct {
-		input     string
-		wantField string
-		wantOp    string
-		wantValue string
+		input string
+		want  condExpr
 	}{
-		{"field=value", "field", "=", "value"},
-		{"field!=value", "field", "!=", "value"},
-		{"flag=true", "flag", "=", "true"},
-		{"flag!=", "flag", "!=", ""},
-		{"field", "field", "!=", ""},
+		{"field=value", condCompare{"field", "=", "value"}},
+		{"field!=value", condCompare{"field", "!=", "value"}},
+		{"flag=true", condCompare{"flag", "=", "true"}},
+		{"flag!=", condCompare{"flag", "!=", ""}},
+		{"field", condNonEmpty{"field"}},
+		{"c:v=libx264", condCompare{"c:v", "=", "libx264"}},
+		// 旧写法，值可以包含空格和其他字符
+		{"title=hello world", condCompare{"title", "=", "hello world"}},
+		{"size!=1920x1080 (HD)", condCompare{"size", "!=", "1920x1080 (HD)"}},
+		{"mode='x", condCompare{"mode", "=", "'x"}},
 	}
 	for _, tt := range tests {
-		field, op, value := parseCondition(tt.input)
-		if field != tt.wantField || op != tt.wantOp || value != tt.wantValue {
-			t.Errorf("parseCondition(%q) = (%q, %q, %q), want (%q, %q, %q)",
-				tt.input, field, op, value, tt.wantField, tt.wantOp, tt.wantValue)
+		got, err := compileCondition(tt.input)
+		if err != nil {
+			t.Errorf("compileCondition(%q) error: %v", tt.input, err)
+			continue
 		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("compileCondition(%q) = %#v, want %#v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestConditionEval(t *testing.T) {
+	values := map[string]string{
+		"mode":  "advanced",
+		"crf":   "18",
+		"url":   "https://example.com",
+		"flag":  "true",
+		"empty": "",
+	}
+	get := func(f string) string { return values[f] }
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"mode=advanced && flag", true},
+		{"mode=simple || flag=true", true},
+		{"!(mode=advanced)", false},
+		{"!empty", true},
+		{"empty", false},
+		{"crf < 20", true},
+		{"crf >= 20", false},
+		{"crf = 18.0", true},
+		{"crf > abc", false},
+		{"mode in [simple, advanced]", true},
+		{"mode in ['a', 'b']", false},
+		{"url ~ '^https'", true},
+		{"url !~ '^https'", false},
+		{"(mode=simple || crf<=18) && !empty", true},
+		{`mode == "advanced"`, true},
+	}
+	for _, tt := range tests {
+		expr, err := compileCondition(tt.input)
+		if err != nil {
+			t.Errorf("compileCondition(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got := expr.eval(condValues(get)); got != tt.want {
+			t.Errorf("eval(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+// 多值字段的 condGetter
+type condLists map[string][]string
+
+func (m condLists) values(field string) []string { return m[field] }
+func (m condLists) isSet(field string) bool      { return len(m[field]) > 0 }
+
+func TestConditionEvalMulti(t *testing.T) {
+	get := condLists{
+		"tags": {"a", "b"},
+		"nums": {"1", "7"},
+		"none": nil,
+	}
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"tags=a", true},
+		{"tags=b", true},
+		{"tags='a,b'", false},
+		{"tags!=a", false},
+		{"tags!=c", true},
+		{"tags in [b, c]", true},
+		{"tags in [c]", false},
+		{"tags ~ '^b$'", true},
+		{"tags !~ '^b$'", false},
+		{"tags !~ '^c$'", true},
+		{"nums > 5", true},
+		{"nums < 1", false},
+		{"none=", true},
+		{"none!=x", true},
+		{"none", false},
+	}
+	for _, tt := range tests {
+		expr, err := compileCondition(tt.input)
+		if err != nil {
+			t.Errorf("compileCondition(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got := expr.eval(get); got != tt.want {
+			t.Errorf("eval(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCompileConditionErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"(mode=a",
+		"mode=a &&",
+		"mode in [a, b",
+		"url ~ '[a-'",
+		"a & b",
+		"mode=a b && flag",
+		"crf <",
+		"crf >= && flag",
+	} {
+		if _, err := compileCondition(input); err == nil {
+			t.Errorf("compileCondition(%q) should fail", input)
+		}
+	}
+}
+
+func TestConditionFields(t *testing.T) {
+	expr, err := compileCondition("a=1 && (b || !c) && a != 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conditionFields(expr); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("conditionFields() = %v, want [a b c]", got)
+	}
+}
+
+func TestSetupConditionsMultipleFields(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "enable", Type: "bool"},
+			{Name: "crf", Type: "number"},
+			{Name: "extra", Type: "string", Condition: "enable && crf < 20"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	extra := ui.widgets["extra"].(*widget.Entry)
+	ui.widgets["enable"].(*widget.Check).SetChecked(true)
+	if !extra.Disabled() {
+		t.Error("extra should be disabled when crf is empty")
+	}
+	// 任一被引用字段变化都会重新计算
+	setEntryText(ui.widgets["crf"], "18")
+	if extra.Disabled() {
+		t.Error("extra should be enabled when enable && crf < 20")
+	}
+	ui.widgets["enable"].(*widget.Check).SetChecked(false)
+	if !extra.Disabled() {
+		t.Error("extra should be disabled when enable is false")
+	}
+}
+
+func TestSetupConditionsWithMulti(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "tags", Type: "string", Multi: true},
+			{Name: "extra", Type: "string", Condition: "tags"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	extra := ui.widgets["extra"].(*widget.Entry)
+	if !extra.Disabled() {
+		t.Error("extra should be disabled when tags is empty")
+	}
+	setEntryText(ui.widgets["tags"].(*multiWidget).rows[0], "a")
+	if extra.Disabled() {
+		t.Error("extra should be enabled when tags has a value")
 	}
 }
 
@@ -43,13 +215,13 @@ func TestCheckConditionEqual(t *testing.T) {
 	ui.Build()
 
 	// mode=simple，条件不满足
-	ui.widgets["mode"].(*widget.Select).SetSelected("simple")
+	setSelected(ui.widgets["mode"], "simple")
 	if ui.checkCondition(&app.Items[1]) {
 		t.Error("checkCondition() = true, want false (mode=simple)")
 	}
 
 	// mode=advanced，条件满足
-	ui.widgets["mode"].(*widget.Select).SetSelected("advanced")
+	setSelected(ui.widgets["mode"], "advanced")
 	if !ui.checkCondition(&app.Items[1]) {
 		t.Error("checkCondition() = false, want true (mode=advanced)")
 	}
@@ -163,6 +335,62 @@ func TestCheckConditionWithEntry(t *testing.T) {
 	}
 }
 
+// 旧版本配置中值含空格的条件
+func TestCheckConditionLegacyValue(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "preset", Type: "string"},
+			{Name: "extra", Type: "string", Condition: "preset=very slow"},
+		},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.Build()
+
+	setEntryText(ui.widgets["preset"], "very slow")
+	if !ui.checkCondition(&app.Items[1]) {
+		t.Error("checkCondition() = false, want true (preset=very slow)")
+	}
+	setEntryText(ui.widgets["preset"], "very")
+	if ui.checkCondition(&app.Items[1]) {
+		t.Error("checkCondition() = true, want false (preset=very)")
+	}
+}
+
+// tristate 为 Off 时生成参数，但字段名条件和必填检查视为空
+func TestConditionTristateOff(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "force", Type: "bool", Tristate: true, Required: true},
+			{Name: "extra", Type: "string", Condition: "force"},
+			{Name: "reason", Type: "string", Condition: "force=false"},
+		},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.Build()
+
+	radio := ui.widgets["force"].(*widget.RadioGroup)
+	radio.SetSelected(triStateOff)
+	if ui.checkCondition(&app.Items[1]) {
+		t.Error("checkCondition(force) = true, want false when force is Off")
+	}
+	if !ui.checkCondition(&app.Items[2]) {
+		t.Error("checkCondition(force=false) = false, want true when force is Off")
+	}
+	if err := ui.validateRequired(); err == nil {
+		t.Error("validateRequired() = nil, want error when force is Off")
+	}
+
+	radio.SetSelected(triStateOn)
+	if !ui.checkCondition(&app.Items[1]) {
+		t.Error("checkCondition(force) = false, want true when force is On")
+	}
+	if err := ui.validateRequired(); err != nil {
+		t.Errorf("validateRequired() = %v", err)
+	}
+}
+
 func TestParseImportance(t *testing.T) {
 	tests := []struct {
 		color string
@@ -203,13 +431,13 @@ func TestSetupConditionsWithSelect(t *testing.T) {
 	}
 
 	// 设置 mode=advanced，extra 应该启用
-	ui.widgets["mode"].(*widget.Select).SetSelected("advanced")
+	setSelected(ui.widgets["mode"], "advanced")
 	if extraWidget.Disabled() {
 		t.Error("extra should be enabled when mode=advanced")
 	}
 
 	// 设置 mode=simple，extra 应该禁用
-	ui.widgets["mode"].(*widget.Select).SetSelected("simple")
+	setSelected(ui.widgets["mode"], "simple")
 	if !extraWidget.Disabled() {
 		t.Error("extra should be disabled when mode=simple")
 	}
@@ -342,3 +570,81 @@ func TestUpdateWidgetStateWithPicker(t *testing.T) {
 		t.Error("picker button should be enabled")
 	}
 }
+
+func TestConditionModeHide(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "advanced", Type: "bool"},
+			{Name: "level", Type: "number", Description: "1-9", Condition: "advanced", ConditionMode: "hide"},
+			{Name: "name", Type: "string"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	rows := ui.rows["level"]
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2 (field and description)", len(rows))
+	}
+	setEntryText(ui.widgets["level"], "5")
+	for _, row := range rows {
+		if row.Visible() {
+			t.Error("row should be hidden when condition is false")
+		}
+	}
+	if args := ui.BuildArgs(); len(args) != 0 {
+		t.Errorf("hidden item should not be in args, got %v", args)
+	}
+
+	ui.widgets["advanced"].(*widget.Check).SetChecked(true)
+	for _, row := range rows {
+		if !row.Visible() {
+			t.Error("row should be visible when condition is true")
+		}
+	}
+	if ui.widgets["level"].(*widget.Entry).Disabled() {
+		t.Error("hidden mode should not disable the widget")
+	}
+	want := []string{"--advanced", "--level=5"}
+	if args := ui.BuildArgs(); !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestConditionModeAppDefault(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd", ConditionMode: "hide"},
+		Items: []Item{
+			{Name: "advanced", Type: "bool"},
+			{Name: "level", Type: "number", Condition: "advanced"},
+			{Name: "extra", Type: "string", Condition: "advanced", ConditionMode: "disable"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	if ui.rows["level"][0].Visible() {
+		t.Error("level should inherit hide mode from command")
+	}
+	if !ui.rows["extra"][0].Visible() {
+		t.Error("extra should stay visible with condition_mode = disable")
+	}
+	if !ui.widgets["extra"].(*widget.Entry).Disabled() {
+		t.Error("extra should be disabled")
+	}
+}
+
+func TestNoSpaceVBoxSkipsHiddenRows(t *testing.T) {
+	a := widget.NewLabel("a")
+	b := widget.NewLabel("b")
+	c := container.New(&noSpaceVBox{}, a, b)
+	full := c.MinSize().Height
+	b.Hide()
+	c.Refresh()
+	if h := c.MinSize().Height; h >= full {
+		t.Errorf("MinSize height = %v, want less than %v after hiding a row", h, full)
+	}
+}
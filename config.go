@@ -1,29 +1,74 @@
 package main
 
+import "fmt"
+
 type Config struct {
 	Title  string  `toml:"title"`
 	Width  float32 `toml:"width"`
 	Height float32 `toml:"height"`
 	Apps   []App   `toml:"apps"`
+	// 所有 app 同时执行的命令数上限，0 为不限
+	MaxJobs int `toml:"max_jobs"`
+	// 配置文件绝对路径，用于保存状态
+	Path string `toml:"-"`
 }
 
 type App struct {
-	Command Command `toml:"command"`
-	Items   []Item  `toml:"items"`
+	Command Command  `toml:"command"`
+	Items   []Item   `toml:"items"`
+	Presets []Preset `toml:"presets"`
+	// 批量执行，未设置时每次执行一次
+	Batch *Batch `toml:"batch"`
+}
+
+// 批量执行: 对 item 中的每个输入文件执行一次命令
+type Batch struct {
+	Item string `toml:"item"`
+	// 输入为目录时匹配的文件，默认为 *
+	Glob string `toml:"glob"`
+	// 同时执行的数量，默认 1
+	Parallel int `toml:"parallel"`
 }
 
 type Command struct {
-	Path       string            `toml:"path"`
-	Name       string            `toml:"name"`
-	Args       []string          `toml:"args"`
-	Mode       string            `toml:"mode"`
-	Output     string            `toml:"output"`
-	Debug      bool              `toml:"debug"`
-	RunText    string            `toml:"run_text"`
-	RunColor   string            `toml:"run_color"`
-	DebugText  string            `toml:"debug_text"`
-	DebugColor string            `toml:"debug_color"`
-	Env        map[string]string `toml:"env"`
+	Path string   `toml:"path"`
+	Name string   `toml:"name"`
+	Args []string `toml:"args"`
+	// 放在所有字段参数之后的固定参数
+	ArgsAfter []string `toml:"args_after"`
+	// 位置参数以 - 开头时自动插入 --
+	EndOfOptions bool              `toml:"end_of_options"`
+	Mode         string            `toml:"mode"`
+	Output       string            `toml:"output"`
+	Debug        bool              `toml:"debug"`
+	RunText      string            `toml:"run_text"`
+	RunColor     string            `toml:"run_color"`
+	DebugText    string            `toml:"debug_text"`
+	DebugColor   string            `toml:"debug_color"`
+	Env          map[string]string `toml:"env"`
+	// 条件不满足时的默认处理方式: disable 或 hide
+	ConditionMode string `toml:"condition_mode"`
+	// 标准输入来源
+	Stdin *Stdin `toml:"stdin"`
+	// 工作目录，支持 ~、环境变量和 ${name} 引用字段的值
+	Dir string `toml:"dir"`
+	// 在表单中显示工作目录选择器
+	DirPicker bool `toml:"dir_picker"`
+	// 同时执行的数量上限，超出的排队等待，0 为不限
+	MaxJobs int `toml:"max_jobs"`
+	// 取消时先发送的信号（默认 SIGTERM），超过 stop_timeout（如 5s）仍未结束则 SIGKILL 整个进程组
+	StopSignal  string `toml:"stop_signal"`
+	StopTimeout string `toml:"stop_timeout"`
+}
+
+// 标准输入来源，只能设置其中一项
+type Stdin struct {
+	// 文本模板，可用 ${name} 引用字段的值
+	Text string `toml:"text"`
+	// 使用字段的值
+	Item string `toml:"item"`
+	// 文件路径，可用 ${name} 引用字段的值
+	File string `toml:"file"`
 }
 
 type Item struct {
@@ -39,18 +84,112 @@ type Item struct {
 	Default     any      `toml:"default"`
 	Choices     []string `toml:"choices"`
 	Placeholder string   `toml:"placeholder"`
-	Picker      string   `toml:"picker"`
-	PickerText  string   `toml:"picker_text"`
-	Separator   string   `toml:"separator"`
-	Multi       bool     `toml:"multi"`
+	// choice 类型的显示方式: select、radio 或 auto（选项少时用 radio）
+	Style      string `toml:"style"`
+	Picker     string `toml:"picker"`
+	PickerText string `toml:"picker_text"`
+	// 选择器的扩展名过滤（如 *.mp4）和起始目录
+	Filters  []string `toml:"filters"`
+	StartDir string   `toml:"start_dir"`
+	// 建议值模板，引用的字段变化时填入，如 ${input:noext}.mp4
+	Suggest   string `toml:"suggest"`
+	Separator string `toml:"separator"`
+	// 参数模板，每项为一个参数，可用 ${value} 和 ${name} 引用字段的值
+	Template []string `toml:"template"`
+	// bool 类型: 勾选/未勾选时的值或参数模板，tristate 时可以不选择
+	TrueValue  string   `toml:"true_value"`
+	FalseValue string   `toml:"false_value"`
+	On         []string `toml:"on"`
+	Off        []string `toml:"off"`
+	Tristate   bool     `toml:"tristate"`
+	// 参数顺序，数值小的在前，相同时按声明顺序
+	Order int `toml:"order"`
+	// 相对固定参数的位置: before 或 after（默认）
+	Position string `toml:"position"`
+	Multi    bool   `toml:"multi"`
+	// 多值字段和 multichoice: 值个数范围，设置 join 时用该字符串连接为一个参数
+	MinCount int    `toml:"min_count"`
+	MaxCount int    `toml:"max_count"`
+	Join     string `toml:"join"`
+	// text 类型
+	Rows   int  `toml:"rows"`
+	AsFile bool `toml:"as_file"`
+	Stdin  bool `toml:"stdin"`
 	// 验证
 	Required  bool   `toml:"required"`
 	Validate  string `toml:"validate"`
 	Min       any    `toml:"min"`
 	Max       any    `toml:"max"`
 	Condition string `toml:"condition"`
+	// 条件不满足时禁用还是隐藏，为空时使用 command 中的设置
+	ConditionMode string `toml:"condition_mode"`
+	// 条件不满足而被禁用时仍然生成参数
+	EmitWhenDisabled bool `toml:"emit_when_disabled"`
+	// 是否记住上次的值，默认 true
+	Remember *bool `toml:"remember"`
 }
 
 func (i *Item) IsLabel() bool {
 	return i.Text != "" && i.Name == ""
 }
+
+// 密码字段的值不显示、不保存
+func (i *Item) IsSecret() bool {
+	return i.Type == "password"
+}
+
+// 值不直接出现在参数中（写入临时文件或标准输入）
+func (i *Item) Indirect() bool {
+	return i.Type == "text" && (i.AsFile || i.Stdin)
+}
+
+func (i *Item) Remembered() bool {
+	if i.IsSecret() {
+		return false
+	}
+	return i.Remember == nil || *i.Remember
+}
+
+// 条件不满足时是否隐藏整行
+func (a *App) HideWhenFalse(i *Item) bool {
+	mode := i.ConditionMode
+	if mode == "" {
+		mode = a.Command.ConditionMode
+	}
+	return mode == "hide"
+}
+
+// auto 样式下不超过该数量的选项显示为单选按钮
+const radioChoiceLimit = 5
+
+// choice 是否显示为单选按钮组
+func (i *Item) UseRadio() bool {
+	return i.Style == "radio" || i.Style == "auto" && len(i.Choices) <= radioChoiceLimit
+}
+
+// 是否有多个值，bool 忽略 multi
+func (i *Item) MultiValued() bool {
+	return i.Multi && i.Type != "bool" || i.Type == "multichoice"
+}
+
+// 默认值，格式与 getWidgetValue 一致
+func (i *Item) DefaultValues() []string {
+	if i.MultiValued() {
+		// 可以是单个值或数组
+		return presetItemValues(i.Default)
+	}
+	if i.Default == nil {
+		return nil
+	}
+	if i.Type == "bool" {
+		v, ok := i.Default.(bool)
+		switch {
+		case ok && v:
+			return []string{"true"}
+		case ok && i.Tristate:
+			return []string{"false"}
+		}
+		return nil
+	}
+	return []string{fmt.Sprintf("%v", i.Default)}
+}
@@ -35,7 +35,7 @@ label = "Message"
 default = "world"
 `
 	path := writeTempFile(t, toml)
-	cfg := loadConfig(path)
+	cfg := mustLoadConfig(t, path)
 
 	if cfg.Title != "Test App" {
 		t.Errorf("Title = %q, want %q", cfg.Title, "Test App")
@@ -111,7 +111,7 @@ path = "echo"
 name = "Test"
 `
 	path := writeTempFile(t, toml)
-	cfg := loadConfig(path)
+	cfg := mustLoadConfig(t, path)
 
 	if cfg.Width != 400 {
 		t.Errorf("default Width = %v, want 400", cfg.Width)
@@ -174,7 +174,7 @@ choices = ["a", "b", "c"]
 default = "b"
 `
 	path := writeTempFile(t, toml)
-	cfg := loadConfig(path)
+	cfg := mustLoadConfig(t, path)
 
 	items := cfg.Apps[0].Items
 	if items[0].Default != "hello" {
@@ -254,7 +254,7 @@ type = "string"
 condition = "flag=true"
 `
 	path := writeTempFile(t, toml)
-	cfg := loadConfig(path)
+	cfg, _ := loadConfig(path)
 
 	items := cfg.Apps[0].Items
 	if items[0].Picker != "file" {
@@ -304,3 +304,12 @@ func writeTempFile(t *testing.T, content string) string {
 	}
 	return path
 }
+
+func mustLoadConfig(t *testing.T, path string) *Config {
+	t.Helper()
+	cfg, diags := loadConfig(path)
+	if diags.HasErrors() {
+		t.Fatalf("loadConfig() diagnostics:\n%s", diags)
+	}
+	return cfg
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -24,111 +32,535 @@ func (u *AppUI) Execute() {
 		return
 	}
 
-	args := u.BuildArgs()
-	cmd := exec.Command(u.app.Command.Path, args...)
+	if err := u.saveLastValues(); err != nil {
+		fmt.Fprintln(os.Stderr, "save state:", err)
+	}
 
-	// 设置环境变量
-	if len(u.app.Command.Env) > 0 {
-		cmd.Env = os.Environ()
-		for k, v := range u.app.Command.Env {
-			cmd.Env = append(cmd.Env, k+"="+v)
+	if u.batchItem() != nil {
+		u.executeBatch()
+		return
+	}
+	dir, err := u.workDir()
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+	spec, err := u.prepareRun()
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+	u.run(spec, u.app.Command.Env, dir)
+}
+
+// 一次执行的参数和输入
+type runSpec struct {
+	// 可执行文件，为空时使用 command.path
+	path string
+	args []string
+	// 隐藏密码后用于显示和记录的参数
+	masked []string
+	// 标准输入，为 nil 时不提供
+	stdin io.Reader
+	// 执行结束后关闭的文件
+	closers []io.Closer
+	// 执行结束后删除的临时文件
+	tempFiles []string
+	// 由 open 写入的临时文件和打开的标准输入文件
+	texts     []textFile
+	stdinPath string
+}
+
+// 等待写入的 as_file 临时文件
+type textFile struct {
+	path string
+	text string
+}
+
+func (u *AppUI) commandPath(spec *runSpec) string {
+	if spec.path != "" {
+		return spec.path
+	}
+	return u.app.Command.Path
+}
+
+// 记录中的参数能否原样重新执行
+func (s *runSpec) complete() bool {
+	return slices.Equal(s.args, s.masked) && len(s.tempFiles) == 0 && len(s.texts) == 0 &&
+		s.stdin == nil && s.stdinPath == ""
+}
+
+// 写入临时文件并打开标准输入文件，失败时释放已创建的文件
+func (s *runSpec) open() error {
+	texts := s.texts
+	s.texts = nil
+	for _, t := range texts {
+		if err := writeTextFile(t.path, t.text); err != nil {
+			s.release()
+			return err
+		}
+		s.tempFiles = append(s.tempFiles, t.path)
+	}
+	if s.stdinPath != "" {
+		f, err := os.Open(s.stdinPath)
+		if err != nil {
+			s.release()
+			return fmt.Errorf("open stdin file: %w", err)
 		}
+		s.stdinPath = ""
+		s.stdin = f
+		s.closers = append(s.closers, f)
 	}
+	return nil
+}
 
-	if u.app.Command.Mode == "visible" {
-		if runtime.GOOS == "darwin" {
-			// macOS: 使用 osascript 启动，确保进程独立运行
-			script := u.app.Command.Path + " " + strings.Join(args, " ")
-			exec.Command("osascript", "-e", fmt.Sprintf(`do shell script "%s"`, script)).Start()
-		} else if runtime.GOOS == "windows" {
-			// Windows: 使用 cmd /c start 启动独立进程
-			cmdArgs := append([]string{"/c", "start", "", u.app.Command.Path}, args...)
-			exec.Command("cmd", cmdArgs...).Start()
-		} else {
-			cmd.Start()
+// 关闭文件并删除临时文件
+func (s *runSpec) release() {
+	for _, c := range s.closers {
+		c.Close()
+	}
+	removeFiles(s.tempFiles)
+}
+
+func removeFiles(paths []string) {
+	for _, p := range paths {
+		os.Remove(p)
+	}
+}
+
+// 根据表单生成参数，text 字段按配置写入临时文件或标准输入
+func (u *AppUI) prepareRun() (*runSpec, error) {
+	spec, err := u.planRun()
+	if err != nil {
+		return nil, err
+	}
+	if err := spec.open(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// 读取表单并确定文件路径，文件由 open 写入或打开
+func (u *AppUI) planRun() (*runSpec, error) {
+	spec := &runSpec{}
+	files := make(map[string]string)
+	for i := range u.app.Items {
+		item := &u.app.Items[i]
+		if !item.Indirect() || u.excludedByCondition(item) {
+			continue
+		}
+		val := u.getWidgetValue(item, u.widgets[item.Name])
+		if item.Stdin {
+			spec.stdin = strings.NewReader(val)
+			continue
 		}
+		if val == "" {
+			continue
+		}
+		path := tempTextPath(item.Name)
+		files[item.Name] = path
+		spec.texts = append(spec.texts, textFile{path, val})
+	}
+	if err := u.prepareStdin(spec); err != nil {
+		return nil, err
+	}
+	spec.args = u.buildArgs(false, files)
+	spec.masked = u.buildArgs(true, files)
+	return spec, nil
+}
+
+// 按 [apps.command] stdin 设置标准输入
+func (u *AppUI) prepareStdin(spec *runSpec) error {
+	in := u.app.Command.Stdin
+	if in == nil {
+		return nil
+	}
+	switch {
+	case in.Text != "":
+		spec.stdin = strings.NewReader(expandTemplate(in.Text, u.templateValue))
+	case in.Item != "":
+		spec.stdin = strings.NewReader(u.templateValue(in.Item))
+	case in.File != "":
+		path := expandTemplate(in.File, u.templateValue)
+		if path == "" {
+			return fmt.Errorf("stdin file is empty")
+		}
+		spec.stdinPath = path
+	}
+	return nil
+}
+
+// 模板中字段的值，多值字段以换行连接，条件不满足的字段为空
+func (u *AppUI) templateValue(ref string) string {
+	if v, ok := u.batchValue(ref); ok {
+		return v
+	}
+	name, mod := splitTemplateRef(ref)
+	item := u.findItem(name)
+	if item == nil || item.IsLabel() || u.excludedByCondition(item) {
+		return ""
+	}
+	return applyPathModifier(strings.Join(u.itemValues(item), "\n"), mod)
+}
+
+// 字段的临时文件路径，文件由 writeTextFile 创建
+func tempTextPath(name string) string {
+	suffix := strconv.FormatUint(rand.Uint64(), 36)
+	return filepath.Join(os.TempDir(), "cliface-"+sanitizeFileName(name)+"-"+suffix+".txt")
+}
+
+// 将文本写入新文件，文件已存在时失败
+func writeTextFile(path, text string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// 字段名中不能用于文件名的字符替换为下划线
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// 按给定参数执行命令并记录历史
+func (u *AppUI) run(spec *runSpec, env map[string]string, dir string) {
+	cmd := u.newCommand(spec, env, dir)
+	entry := u.newHistoryEntry(spec, env, dir)
+
+	if u.app.Command.Mode == "visible" {
+		u.executeVisible(cmd, spec, entry)
 		return
 	}
 
 	switch u.app.Command.Output {
 	case "realtime":
-		u.executeRealtime(cmd)
+		u.executeRealtime(cmd, entry)
 	case "realtime-console":
-		u.executeConsole(cmd)
+		u.executeConsole(cmd, entry)
 	default:
-		u.executeDialog(cmd)
+		u.executeDialog(cmd, entry)
+	}
+}
+
+// 在独立窗口中启动，不等待结果
+func (u *AppUI) executeVisible(cmd *exec.Cmd, spec *runSpec, entry *HistoryEntry) {
+	launcher := cmd
+	if runtime.GOOS == "darwin" {
+		// macOS: 使用 osascript 启动，确保进程独立运行
+		script := u.commandPath(spec) + " " + strings.Join(spec.args, " ")
+		launcher = exec.Command("osascript", "-e", fmt.Sprintf(`do shell script "%s"`, script))
+	} else if runtime.GOOS == "windows" {
+		// Windows: 使用 cmd /c start 启动独立进程
+		cmdArgs := append([]string{"/c", "start", "", u.commandPath(spec)}, spec.args...)
+		launcher = exec.Command("cmd", cmdArgs...)
+	}
+	if launcher != cmd {
+		setProcessGroup(launcher)
+	}
+	job := u.jobs.add(u, launcher, entry)
+	go func() {
+		if err := u.jobs.start(job); err != nil {
+			u.finishRun(entry, err, "")
+			u.jobs.finish(job, err)
+			return
+		}
+		// 启动的进程退出后才释放文件
+		release := entry.release
+		entry.release = nil
+		// 独立运行的进程无法得知退出码，记为 -1
+		u.finishRun(entry, errDetached, "")
+		u.jobs.detach(job)
+		err := launcher.Wait()
+		if release != nil {
+			release()
+		}
+		u.jobs.finish(job, err)
+	}()
+}
+
+// 执行记录，结束后由 finishRun 补充结果
+func (u *AppUI) newHistoryEntry(spec *runSpec, env map[string]string, dir string) *HistoryEntry {
+	entry := &HistoryEntry{
+		Time:    time.Now(),
+		App:     u.stateKey,
+		Path:    u.commandPath(spec),
+		Args:    spec.masked,
+		Partial: !spec.complete(),
+		Env:     env,
+		Dir:     dir,
+		release: spec.release,
+	}
+	if entry.Dir == "" {
+		entry.Dir, _ = os.Getwd()
+	}
+	return entry
+}
+
+// 按给定参数创建命令
+func (u *AppUI) newCommand(spec *runSpec, env map[string]string, dir string) *exec.Cmd {
+	cmd := exec.Command(u.commandPath(spec), spec.args...)
+	cmd.Dir = dir
+	setProcessGroup(cmd)
+	if spec.stdin != nil {
+		cmd.Stdin = spec.stdin
+	}
+
+	// 设置环境变量
+	if len(env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
 	}
+	return cmd
 }
 
-func (u *AppUI) executeDialog(cmd *exec.Cmd) {
-	done := make(chan struct{})
+// 记录执行结果，排队时取消的不记录
+func (u *AppUI) finishRun(entry *HistoryEntry, err error, output string) {
+	if entry.release != nil {
+		entry.release()
+	}
+	if errors.Is(err, errJobCanceled) {
+		return
+	}
+	entry.Duration = time.Since(entry.Time)
+	entry.ExitCode = exitCode(err)
+	if len(output) > maxOutputTail {
+		output = output[len(output)-maxOutputTail:]
+	}
+	entry.Output = output
+	if u.history == nil {
+		return
+	}
+	if err := u.history.Add(*entry); err != nil {
+		fmt.Fprintln(os.Stderr, "save history:", err)
+	}
+}
+
+var errDetached = errors.New("process detached")
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		return ee.ExitCode()
+	}
+	return -1
+}
+
+func (u *AppUI) executeDialog(cmd *exec.Cmd, entry *HistoryEntry) {
+	job := u.jobs.add(u, cmd, entry)
+	var output bytes.Buffer
+	w := io.MultiWriter(&output, job.output)
+	cmd.Stdout = w
+	cmd.Stderr = w
 	prog := dialog.NewCustomConfirm("执行中", "取消", "", widget.NewProgressBarInfinite(), func(cancel bool) {
 		if cancel {
-			cmd.Process.Kill()
+			u.jobs.cancel(job)
 		}
 	}, u.window)
 	prog.Show()
 	go func() {
-		output, err := cmd.CombinedOutput()
-		close(done)
-		prog.Hide()
-		text := string(output)
-		if err != nil {
-			text += "\n\n错误: " + err.Error()
-		}
-		entry := widget.NewMultiLineEntry()
-		entry.SetText(text)
-		entry.Wrapping = fyne.TextWrapWord
-		win := fyne.CurrentApp().NewWindow("Output")
-		win.SetContent(container.NewScroll(entry))
-		win.Resize(fyne.NewSize(500, 400))
-		win.Show()
+		err := u.jobs.start(job)
+		if err == nil {
+			err = cmd.Wait()
+		}
+		u.finishRun(entry, err, output.String())
+		u.jobs.finish(job, err)
+		fyne.Do(func() {
+			prog.Hide()
+			if errors.Is(err, errJobCanceled) {
+				return
+			}
+			text := output.String() + "\n\n[" + u.jobs.result(job) + "]"
+			out := widget.NewMultiLineEntry()
+			out.SetText(text)
+			out.Wrapping = fyne.TextWrapWord
+			win := fyne.CurrentApp().NewWindow("Output")
+			win.SetContent(container.NewScroll(out))
+			win.Resize(fyne.NewSize(500, 400))
+			win.Show()
+		})
 	}()
 }
 
-func (u *AppUI) executeRealtime(cmd *exec.Cmd) {
-	stdout, _ := cmd.StdoutPipe()
-	cmd.Stderr = cmd.Stdout
+func (u *AppUI) executeRealtime(cmd *exec.Cmd, entry *HistoryEntry) {
+	// 自行创建管道: 排队时被取消不会启动命令，此时由这里关闭全部管道
+	stdout, stdoutW, err := os.Pipe()
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stdoutW
+	pipes := []*os.File{stdout, stdoutW}
+	// 子进程一端，启动后关闭
+	childEnds := []*os.File{stdoutW}
 
-	entry := widget.NewMultiLineEntry()
-	entry.Wrapping = fyne.TextWrapWord
+	// 没有配置标准输入时提供输入行，用于回答交互提示
+	var stdin *os.File
+	if cmd.Stdin == nil {
+		stdinR, stdinW, err := os.Pipe()
+		if err != nil {
+			closeFiles(pipes)
+			dialog.ShowError(err, u.window)
+			return
+		}
+		cmd.Stdin = stdinR
+		stdin = stdinW
+		pipes = append(pipes, stdinR, stdinW)
+		childEnds = append(childEnds, stdinR)
+	}
+
+	output := widget.NewMultiLineEntry()
+	output.Wrapping = fyne.TextWrapWord
+	lines := &outputLines{max: 500}
+	show := func(s string) {
+		text := lines.Append(s)
+		fyne.Do(func() { output.SetText(text) })
+	}
 
 	cancelBtn := widget.NewButton("取消", nil)
 	cancelBtn.Importance = widget.DangerImportance
 
+	var inputBar *fyne.Container
+	input := widget.NewEntry()
+	input.SetPlaceHolder("Input, press Enter to send")
+	eofBtn := widget.NewButton("EOF", nil)
+	if stdin != nil {
+		input.OnSubmitted = func(s string) {
+			if _, err := io.WriteString(stdin, s+"\n"); err != nil {
+				return
+			}
+			input.SetText("")
+			show(s + "\n")
+		}
+		// 关闭标准输入，用于读到文件末尾才输出的命令
+		eofBtn.OnTapped = func() {
+			stdin.Close()
+			input.Disable()
+			eofBtn.Disable()
+		}
+		inputBar = container.NewBorder(nil, nil, nil, eofBtn, input)
+	}
+
 	win := fyne.CurrentApp().NewWindow("Output")
-	win.SetContent(container.NewBorder(cancelBtn, nil, nil, nil, container.NewScroll(entry)))
+	if inputBar != nil {
+		win.SetContent(container.NewBorder(cancelBtn, inputBar, nil, nil, container.NewScroll(output)))
+	} else {
+		win.SetContent(container.NewBorder(cancelBtn, nil, nil, nil, container.NewScroll(output)))
+	}
 	win.Resize(fyne.NewSize(500, 400))
 	win.Show()
 
-	if err := cmd.Start(); err != nil {
-		entry.SetText("Error: " + err.Error())
-		return
-	}
-
+	job := u.jobs.add(u, cmd, entry)
+	// 第一次发送 stop_signal，再次点击立即结束
 	cancelBtn.OnTapped = func() {
-		cmd.Process.Kill()
-		entry.SetText(entry.Text + "\n[已取消]")
+		u.jobs.cancel(job)
+		cancelBtn.SetText("强制结束")
 	}
+	// 超出并发上限时先排队
+	output.SetPlaceHolder("Waiting to start...")
 
 	go func() {
-		scanner := bufio.NewScanner(stdout)
-		var lines []string
-		const maxLines = 500
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
-			if len(lines) > maxLines {
-				lines = lines[len(lines)-maxLines:]
+		if err := u.jobs.start(job); err != nil {
+			closeFiles(pipes)
+			u.finishRun(entry, err, "")
+			u.jobs.finish(job, err)
+			fyne.Do(func() {
+				if !errors.Is(err, errJobCanceled) {
+					output.SetText("Error: " + err.Error())
+				}
+				cancelBtn.Disable()
+				input.Disable()
+				eofBtn.Disable()
+			})
+			return
+		}
+		closeFiles(childEnds)
+		// 按块读取，不以换行结尾的提示也能及时显示
+		buf := make([]byte, 4096)
+		for {
+			n, err := stdout.Read(buf)
+			if n > 0 {
+				job.output.Write(buf[:n])
+				show(string(buf[:n]))
+			}
+			if err != nil {
+				break
 			}
-			entry.SetText(strings.Join(lines, "\n"))
 		}
+		err := cmd.Wait()
+		stdout.Close()
+		if stdin != nil {
+			stdin.Close()
+		}
+		u.finishRun(entry, err, job.output.String())
+		u.jobs.finish(job, err)
+		show("\n[" + u.jobs.result(job) + "]\n")
+		fyne.Do(func() {
+			cancelBtn.Disable()
+			input.Disable()
+			eofBtn.Disable()
+		})
 	}()
 }
 
-func (u *AppUI) executeConsole(cmd *exec.Cmd) {
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	fmt.Printf(">>> %s %s\n", u.app.Command.Path, strings.Join(u.BuildArgs(), " "))
-	cmd.Run()
-	fmt.Println("<<< done")
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// 保留最后 max 行的输出
+type outputLines struct {
+	mu   sync.Mutex
+	max  int
+	text string
+}
+
+func (o *outputLines) Append(s string) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.text += s
+	for n := strings.Count(o.text, "\n"); n > o.max; n-- {
+		o.text = o.text[strings.IndexByte(o.text, '\n')+1:]
+	}
+	return o.text
+}
+
+func (u *AppUI) executeConsole(cmd *exec.Cmd, entry *HistoryEntry) {
+	job := u.jobs.add(u, cmd, entry)
+	cmd.Stdout = io.MultiWriter(os.Stdout, job.output)
+	cmd.Stderr = io.MultiWriter(os.Stderr, job.output)
+	fmt.Printf(">>> %s %s\n", entry.Path, strings.Join(entry.Args, " "))
+	go func() {
+		if err := u.jobs.start(job); err != nil {
+			u.finishRun(entry, err, "")
+			u.jobs.finish(job, err)
+			fmt.Println("<<<", err)
+			return
+		}
+		err := cmd.Wait()
+		u.finishRun(entry, err, job.output.String())
+		u.jobs.finish(job, err)
+		fmt.Println("<<<", u.jobs.result(job))
+	}()
 }
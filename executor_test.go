@@ -0,0 +1,261 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestPrepareRunTextAsFile(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "curl"},
+		Items: []Item{
+			{Name: "data-binary", Type: "text", AsFile: true, Separator: " "},
+			{Name: "url", Type: "string", Positional: true},
+		},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.Build()
+
+	ui.widgets["data-binary"].(*widget.Entry).SetText("{\n  \"a\": 1\n}")
+	setEntryText(ui.widgets["url"], "https://example.com")
+
+	if got := ui.BuildArgs(); got[1] != tempFilePlaceholder {
+		t.Errorf("BuildArgs() = %v, want placeholder for the temp file", got)
+	}
+
+	spec, err := ui.prepareRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeFiles(spec.tempFiles)
+	if len(spec.tempFiles) != 1 || spec.args[1] != spec.tempFiles[0] {
+		t.Fatalf("args = %v, temp files = %v", spec.args, spec.tempFiles)
+	}
+	data, err := os.ReadFile(spec.tempFiles[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{\n  \"a\": 1\n}" {
+		t.Errorf("temp file content = %q", data)
+	}
+	if spec.complete() {
+		t.Error("spec with temp files should not be complete")
+	}
+}
+
+func TestRunTextStdinAndCleanup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	app := &App{
+		Command: Command{Path: "sh", Name: "Shell", Args: []string{"-c", `cat; cat "$0"`}, Output: "realtime-console"},
+		Items: []Item{
+			{Name: "file", Type: "text", AsFile: true, Positional: true},
+			{Name: "input", Type: "text", Stdin: true},
+		},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.setStore(nil, 0)
+	ui.history = openHistory(t.TempDir(), "/tmp/tools.toml")
+	ui.Build()
+
+	ui.widgets["input"].(*widget.Entry).SetText("from stdin\n")
+	ui.widgets["file"].(*widget.Entry).SetText("from file\n")
+	ui.Execute()
+	ui.jobs.waitAll()
+
+	entries := ui.history.Entries("Shell")
+	if len(entries) != 1 {
+		t.Fatalf("len(Entries()) = %d, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Output != "from stdin\nfrom file\n" {
+		t.Errorf("Output = %q", e.Output)
+	}
+	if !e.Partial {
+		t.Error("entry using stdin and temp files should be partial")
+	}
+	// 执行结束后临时文件已删除
+	path := e.Args[len(e.Args)-1]
+	if !strings.Contains(path, "cliface-file-") {
+		t.Fatalf("last arg = %q, want temp file path", path)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("temp file %s should be removed, stat err = %v", path, err)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	if got := sanitizeFileName(`c:v/a*b`); got != "c_v_a_b" {
+		t.Errorf("sanitizeFileName() = %q, want c_v_a_b", got)
+	}
+}
+
+func TestRunCommandStdin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	file := filepath.Join(t.TempDir(), "in.txt")
+	if err := os.WriteFile(file, []byte("file content\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		stdin *Stdin
+		want  string
+	}{
+		{"text", &Stdin{Text: "name=${name}\n"}, "name=alice\n"},
+		{"item", &Stdin{Item: "name"}, "alice"},
+		{"file", &Stdin{File: "${path}"}, "file content\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{
+				Command: Command{Path: "sh", Name: "Shell", Args: []string{"-c", "cat"}, Output: "realtime-console", Stdin: tt.stdin},
+				Items: []Item{
+					// sh -c 的额外参数只设置 $0 $1，不影响输出
+					{Name: "name", Type: "string", Positional: true},
+					{Name: "path", Type: "string", Picker: "file", Positional: true},
+				},
+			}
+			ui := NewAppUI(app, test.NewWindow(nil))
+			ui.setStore(nil, 0)
+			ui.history = openHistory(t.TempDir(), "/tmp/tools.toml")
+			ui.Build()
+			setEntryText(ui.widgets["name"], "alice")
+			setContainerEntryText(ui.widgets["path"], file)
+			ui.Execute()
+			ui.jobs.waitAll()
+
+			entries := ui.history.Entries("Shell")
+			if len(entries) != 1 {
+				t.Fatalf("len(Entries()) = %d, want 1", len(entries))
+			}
+			if entries[0].Output != tt.want {
+				t.Errorf("Output = %q, want %q", entries[0].Output, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepareStdinMissingFile(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cat", Stdin: &Stdin{File: filepath.Join(t.TempDir(), "missing.txt")}},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.Build()
+	if _, err := ui.prepareRun(); err == nil {
+		t.Error("prepareRun() should fail when the stdin file does not exist")
+	}
+}
+
+func TestOutputLines(t *testing.T) {
+	o := &outputLines{max: 2}
+	o.Append("a\nb\n")
+	o.Append("Password: ")
+	if got := o.Append("x\nc\n"); got != "Password: x\nc\n" {
+		t.Errorf("Append() = %q", got)
+	}
+}
+
+func TestRunWorkDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	repo := t.TempDir()
+	app := &App{
+		Command: Command{Path: "sh", Name: "Shell", Args: []string{"-c", "pwd"}, Output: "realtime-console", Dir: "${repo}"},
+		Items: []Item{
+			{Name: "repo", Type: "string", Picker: "directory", Positional: true},
+		},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.setStore(nil, 0)
+	ui.history = openHistory(t.TempDir(), "/tmp/tools.toml")
+	ui.Build()
+
+	// 目录不存在时不执行
+	setContainerEntryText(ui.widgets["repo"], filepath.Join(repo, "missing"))
+	if _, err := ui.workDir(); err == nil {
+		t.Error("workDir() should fail for a missing directory")
+	}
+
+	setContainerEntryText(ui.widgets["repo"], repo)
+	ui.Execute()
+	ui.jobs.waitAll()
+	entries := ui.history.Entries("Shell")
+	if len(entries) != 1 {
+		t.Fatalf("len(Entries()) = %d, want 1", len(entries))
+	}
+	got, _ := filepath.EvalSymlinks(strings.TrimSpace(entries[0].Output))
+	want, _ := filepath.EvalSymlinks(repo)
+	if got != want || entries[0].Dir != repo {
+		t.Errorf("pwd = %q, Dir = %q, want %q", got, entries[0].Dir, want)
+	}
+}
+
+func TestDirPicker(t *testing.T) {
+	app := &App{Command: Command{Path: "make", Dir: "~/src", DirPicker: true}}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.Build()
+
+	if ui.dirEntry == nil || ui.dirEntry.Text != "~/src" {
+		t.Fatalf("dir picker should start with command.dir")
+	}
+	dir := t.TempDir()
+	ui.dirEntry.SetText(dir)
+	if got, err := ui.workDir(); err != nil || got != dir {
+		t.Errorf("workDir() = %q, %v, want %q", got, err, dir)
+	}
+	ui.resetToDefaults()
+	if ui.dirEntry.Text != "~/src" {
+		t.Errorf("reset should restore command.dir, got %q", ui.dirEntry.Text)
+	}
+}
+
+func TestRealtimeCanceledWhileQueuedClosesPipes(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("counts open files in /proc")
+	}
+	openFiles := func() int {
+		entries, err := os.ReadDir("/proc/self/fd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(entries)
+	}
+	app := &App{Command: Command{Path: "sh", Name: "Shell", Args: []string{"-c", "exit 0"}, Output: "realtime", MaxJobs: 1}}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.setStore(nil, 0)
+	ui.Build()
+
+	blocker := addShellJob(ui.jobs, ui, "sleep 10")
+	if err := ui.jobs.start(blocker); err != nil {
+		t.Fatal(err)
+	}
+	before := openFiles()
+	ui.Execute()
+	j := ui.jobs.Jobs()[1]
+	waitState(t, ui.jobs, j, jobQueued)
+	ui.jobs.cancel(j)
+	<-j.done
+	if after := openFiles(); after != before {
+		t.Errorf("open files = %d after canceling a queued run, want %d", after, before)
+	}
+	stopJob(ui.jobs, blocker)
+
+	// 正常执行时读完输出后结束
+	app.Command.Args = []string{"-c", "echo hi"}
+	ui.Execute()
+	ui.jobs.waitAll()
+	done := ui.jobs.Jobs()[2]
+	if done.State != jobDone || done.output.String() != "hi\n" {
+		t.Errorf("job = %s with output %q, want done with %q", done.State, done.output.String(), "hi\n")
+	}
+}
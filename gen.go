@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 从帮助文本中解析出的选项
+type helpOption struct {
+	Long        string
+	Short       string
+	Value       string // 值占位符，如 FILE、<N>、string
+	Attached    bool   // --name=VALUE 形式
+	Choices     []string
+	Default     string
+	Description string
+}
+
+var (
+	optionLinePattern = regexp.MustCompile(`^(\s+)(-.*)$`)
+	optionPartPattern = regexp.MustCompile(`^(--?)([A-Za-z][\w.:-]*)(.*)$`)
+	choicesPattern    = regexp.MustCompile(`\{([\w.-]+(?:,[\w.-]+)+)\}`)
+	possiblePattern   = regexp.MustCompile(`\[possible values: ([^\]]+)\]`)
+	defaultPatterns   = []*regexp.Regexp{
+		regexp.MustCompile(`\(default:?\s*"([^"]*)"\)`),
+		regexp.MustCompile(`\(default:?\s*([^)]*)\)`),
+		regexp.MustCompile(`\[default:\s*([^\]]*)\]`),
+	}
+)
+
+// cobra/pflag 的类型名
+var numberValues = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true, "count": true,
+	"N": true, "NUM": true, "NUMBER": true, "INT": true, "INTEGER": true,
+}
+
+var multiValues = map[string]bool{
+	"strings": true, "stringArray": true, "stringSlice": true,
+	"ints": true, "intSlice": true,
+}
+
+// 解析 GNU/getopt、argparse、cobra、clap 风格的帮助文本
+func parseHelp(text string) []helpOption {
+	var opts []helpOption
+	var cur *helpOption
+	indent := 0
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\t", "        "), "\n") {
+		line = strings.TrimRight(line, " \r")
+		if m := optionLinePattern.FindStringSubmatch(line); m != nil {
+			spec, desc := splitSpec(m[2])
+			opt, ok := parseSpec(spec)
+			if ok {
+				opt.Description = desc
+				opts = append(opts, opt)
+				cur = &opts[len(opts)-1]
+				indent = len(m[1])
+				continue
+			}
+		}
+		// 描述续行：缩进比选项行更深
+		trimmed := strings.TrimLeft(line, " ")
+		if cur != nil && trimmed != "" && len(line)-len(trimmed) > indent {
+			if cur.Description != "" {
+				cur.Description += " "
+			}
+			cur.Description += trimmed
+			continue
+		}
+		cur = nil
+	}
+	for i := range opts {
+		opts[i].finish()
+	}
+	return opts
+}
+
+// 选项说明与描述之间至少隔两个空格
+func splitSpec(s string) (spec, desc string) {
+	depth := 0
+	for i := 0; i < len(s)-1; i++ {
+		switch s[i] {
+		case '{', '[', '<':
+			depth++
+		case '}', ']', '>':
+			depth--
+		case ' ':
+			if depth <= 0 && s[i+1] == ' ' {
+				return s[:i], strings.TrimSpace(s[i:])
+			}
+		}
+	}
+	return s, ""
+}
+
+func parseSpec(spec string) (helpOption, bool) {
+	var opt helpOption
+	for _, part := range splitTopLevel(spec, ',') {
+		part = strings.TrimSpace(part)
+		m := optionPartPattern.FindStringSubmatch(part)
+		if m == nil {
+			return opt, false
+		}
+		if m[1] == "--" {
+			opt.Long = m[2]
+		} else if opt.Short == "" {
+			opt.Short = m[2]
+		}
+		rest := m[3]
+		switch {
+		case strings.HasPrefix(rest, "[="):
+			opt.Value = strings.TrimSuffix(rest[2:], "]")
+			opt.Attached = true
+		case strings.HasPrefix(rest, "="):
+			opt.Value = rest[1:]
+			opt.Attached = true
+		case strings.HasPrefix(rest, " "):
+			opt.Value = strings.TrimSpace(rest)
+		case rest != "":
+			return opt, false
+		}
+	}
+	return opt, opt.Long != "" || opt.Short != ""
+}
+
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{', '[', '<':
+			depth++
+		case '}', ']', '>':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func (o *helpOption) finish() {
+	if m := choicesPattern.FindStringSubmatch(o.Value); m != nil {
+		o.Choices = strings.Split(m[1], ",")
+	} else if m := choicesPattern.FindStringSubmatch(o.Description); m != nil && o.Value != "" {
+		o.Choices = strings.Split(m[1], ",")
+	} else if m := possiblePattern.FindStringSubmatch(o.Description); m != nil {
+		for _, c := range strings.Split(m[1], ",") {
+			o.Choices = append(o.Choices, strings.TrimSpace(c))
+		}
+	}
+	for _, re := range defaultPatterns {
+		if m := re.FindStringSubmatch(o.Description); m != nil {
+			o.Default = strings.TrimSpace(m[1])
+			break
+		}
+	}
+}
+
+func (o *helpOption) placeholder() string {
+	return strings.Trim(o.Value, "<>[]")
+}
+
+// 转换为配置项
+func (o *helpOption) toItem() Item {
+	item := Item{Name: o.Long, Description: o.Description}
+	if item.Name == "" {
+		item.Name = o.Short
+		item.Short = true
+	}
+	item.Label = labelFromName(item.Name)
+
+	value := o.placeholder()
+	switch {
+	case o.Value == "":
+		item.Type = "bool"
+		if o.Default == "true" {
+			item.Default = true
+		}
+		return item
+	case len(o.Choices) > 0:
+		item.Type = "choice"
+		item.Choices = o.Choices
+		if slices.Contains(o.Choices, o.Default) {
+			item.Default = o.Default
+		}
+	case numberValues[value]:
+		item.Type = "number"
+		if n, err := strconv.ParseInt(o.Default, 10, 64); err == nil {
+			item.Default = n
+		} else if f, err := strconv.ParseFloat(o.Default, 64); err == nil {
+			item.Default = f
+		}
+	default:
+		item.Type = "string"
+		item.Multi = multiValues[value]
+		if o.Default != "" && o.Default != "[]" {
+			item.Default = o.Default
+		}
+	}
+	if !o.Attached {
+		item.Separator = " "
+	}
+	return item
+}
+
+// output-file -> Output File
+func labelFromName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' })
+	for i, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
+
+// 由帮助文本生成配置项，跳过 help/version 和重名选项
+func helpItems(text string) []Item {
+	var items []Item
+	seen := make(map[string]bool)
+	for _, opt := range parseHelp(text) {
+		if opt.Long == "help" || opt.Long == "version" {
+			continue
+		}
+		item := opt.toItem()
+		if seen[item.Name] {
+			continue
+		}
+		seen[item.Name] = true
+		items = append(items, item)
+	}
+	return items
+}
+
+// 写出生成的 TOML 配置
+func writeGeneratedConfig(w io.Writer, cmd Command, items []Item) {
+	fmt.Fprintf(w, "title = %s\n", tomlString(cmd.Name))
+	fmt.Fprintf(w, "width = 500\nheight = 400\n\n")
+	fmt.Fprintf(w, "[[apps]]\n[apps.command]\n")
+	fmt.Fprintf(w, "path = %s\n", tomlString(cmd.Path))
+	fmt.Fprintf(w, "name = %s\n", tomlString(cmd.Name))
+	fmt.Fprintf(w, "args = %s\n", tomlStrings(cmd.Args))
+	fmt.Fprintf(w, "mode = \"hidden\"\noutput = \"dialog\"\ndebug = true\n")
+	for _, item := range items {
+		fmt.Fprintf(w, "\n[[apps.items]]\n")
+		fmt.Fprintf(w, "name = %s\n", tomlString(item.Name))
+		fmt.Fprintf(w, "type = %s\n", tomlString(item.Type))
+		fmt.Fprintf(w, "label = %s\n", tomlString(item.Label))
+		if item.Short {
+			fmt.Fprintf(w, "short = true\n")
+		}
+		if item.Description != "" {
+			fmt.Fprintf(w, "description = %s\n", tomlString(item.Description))
+		}
+		if len(item.Choices) > 0 {
+			fmt.Fprintf(w, "choices = %s\n", tomlStrings(item.Choices))
+		}
+		switch v := item.Default.(type) {
+		case nil:
+		case string:
+			fmt.Fprintf(w, "default = %s\n", tomlString(v))
+		default:
+			fmt.Fprintf(w, "default = %v\n", v)
+		}
+		if item.Separator != "" {
+			fmt.Fprintf(w, "separator = %s\n", tomlString(item.Separator))
+		}
+		if item.Multi {
+			fmt.Fprintf(w, "multi = true\n")
+		}
+	}
+}
+
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func tomlStrings(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = tomlString(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// cliface gen [-o file] [-from help.txt] -- tool [subcommand...] [-h]
+func runGen(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	out := fs.String("o", "", "output file (default stdout)")
+	from := fs.String("from", "", "read help text from file instead of running the tool")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: cliface gen [-o file] [-from help.txt] -- tool [subcommand...] [-h]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	tool := fs.Args()
+	if len(tool) == 0 && *from == "" {
+		fs.Usage()
+		return 2
+	}
+
+	// 允许显式指定帮助参数，如 gen -- git commit -h
+	helpArg := "--help"
+	if n := len(tool); n > 1 && slices.Contains([]string{"-h", "-help", "--help", "help"}, tool[n-1]) {
+		helpArg = tool[n-1]
+		tool = tool[:n-1]
+	}
+
+	var text string
+	if *from != "" {
+		data, err := os.ReadFile(*from)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		text = string(data)
+	} else {
+		// 很多工具输出帮助后返回非零，只要有输出就继续
+		output, err := exec.Command(tool[0], append(tool[1:], helpArg)...).CombinedOutput()
+		if len(output) == 0 && err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		text = string(output)
+	}
+
+	cmd := Command{Args: []string{}}
+	if len(tool) > 0 {
+		cmd.Path = tool[0]
+		cmd.Args = tool[1:]
+		cmd.Name = strings.Join(append([]string{filepath.Base(tool[0])}, tool[1:]...), " ")
+	} else {
+		cmd.Path = strings.TrimSuffix(filepath.Base(*from), filepath.Ext(*from))
+		cmd.Name = cmd.Path
+	}
+
+	items := helpItems(text)
+	if len(items) == 0 {
+		fmt.Fprintln(stderr, "no options found in help text")
+		return 1
+	}
+
+	if *out == "" {
+		writeGeneratedConfig(stdout, cmd, items)
+		return 0
+	}
+	// 先写入内存，写文件和关闭的错误都要检查，避免留下不完整的配置
+	var buf bytes.Buffer
+	writeGeneratedConfig(&buf, cmd, items)
+	if err := os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHelpGNU(t *testing.T) {
+	help := `Usage: ls [OPTION]... [FILE]...
+List information about the FILEs.
+
+  -a, --all                  do not ignore entries starting with .
+      --block-size=SIZE      with -l, scale sizes by SIZE when printing them;
+                               e.g., '--block-size=M'; see SIZE format below
+      --color[=WHEN]         color the output WHEN; more info below
+  -w, --width=COLS           set output width to COLS.  0 means no limit
+                             -1  disables wrapping
+      --help     display this help and exit
+`
+	items := helpItems(help)
+	if len(items) != 4 {
+		t.Fatalf("len(items) = %d, want 4: %+v", len(items), items)
+	}
+	if items[0].Name != "all" || items[0].Type != "bool" {
+		t.Errorf("items[0] = %+v, want bool all", items[0])
+	}
+	if items[1].Name != "block-size" || items[1].Type != "string" || items[1].Separator != "" {
+		t.Errorf("items[1] = %+v, want string block-size with = separator", items[1])
+	}
+	if !strings.Contains(items[1].Description, "see SIZE format below") {
+		t.Errorf("continuation line not joined: %q", items[1].Description)
+	}
+	if items[1].Label != "Block Size" {
+		t.Errorf("Label = %q, want %q", items[1].Label, "Block Size")
+	}
+	if items[2].Name != "color" || items[2].Type != "string" {
+		t.Errorf("items[2] = %+v, want string color", items[2])
+	}
+	// 以负数开头的续行不是选项
+	if !strings.Contains(items[3].Description, "-1  disables wrapping") {
+		t.Errorf("negative number continuation not joined: %q", items[3].Description)
+	}
+}
+
+func TestParseHelpArgparse(t *testing.T) {
+	help := `usage: prog [-h] [-o OUTPUT] [--mode {fast,slow}] [-n N]
+
+options:
+  -h, --help            show this help message and exit
+  -o OUTPUT, --output OUTPUT
+                        output file (default: out.txt)
+  --mode {fast,slow}    processing mode (default: slow)
+  -n N                  number of workers
+  -q                    quiet
+`
+	items := helpItems(help)
+	if len(items) != 4 {
+		t.Fatalf("len(items) = %d, want 4: %+v", len(items), items)
+	}
+	want := []Item{
+		{Name: "output", Type: "string", Label: "Output", Description: "output file (default: out.txt)", Default: "out.txt", Separator: " "},
+		{Name: "mode", Type: "choice", Label: "Mode", Description: "processing mode (default: slow)", Choices: []string{"fast", "slow"}, Default: "slow", Separator: " "},
+		{Name: "n", Short: true, Type: "number", Label: "N", Description: "number of workers", Separator: " "},
+		{Name: "q", Short: true, Type: "bool", Label: "Q", Description: "quiet"},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(items[i], want[i]) {
+			t.Errorf("items[%d] = %+v, want %+v", i, items[i], want[i])
+		}
+	}
+}
+
+func TestParseHelpCobra(t *testing.T) {
+	help := `Flags:
+  -o, --output string        Output format (default "json")
+      --replicas int         Number of replicas (default 1)
+      --label stringArray    Labels to apply
+  -v, --verbose              Verbose output
+`
+	items := helpItems(help)
+	if len(items) != 4 {
+		t.Fatalf("len(items) = %d, want 4: %+v", len(items), items)
+	}
+	if items[0].Default != "json" || items[0].Separator != " " {
+		t.Errorf("items[0] = %+v, want default json", items[0])
+	}
+	if items[1].Type != "number" || items[1].Default != int64(1) {
+		t.Errorf("items[1] = %+v, want number default 1", items[1])
+	}
+	if !items[2].Multi {
+		t.Errorf("items[2].Multi = false, want true")
+	}
+	if items[3].Type != "bool" {
+		t.Errorf("items[3].Type = %q, want bool", items[3].Type)
+	}
+}
+
+func TestParseHelpClap(t *testing.T) {
+	help := `Options:
+  -f, --format <FORMAT>  Output format [default: text] [possible values: text, json]
+  -j, --jobs <N>         Parallel jobs
+  -h, --help             Print help
+`
+	items := helpItems(help)
+	if len(items) != 2 {
+		t.Fatalf("len(items) = %d, want 2: %+v", len(items), items)
+	}
+	if items[0].Type != "choice" || !reflect.DeepEqual(items[0].Choices, []string{"text", "json"}) || items[0].Default != "text" {
+		t.Errorf("items[0] = %+v, want choice text/json default text", items[0])
+	}
+	if items[1].Type != "number" {
+		t.Errorf("items[1].Type = %q, want number", items[1].Type)
+	}
+}
+
+func TestRunGenFromFile(t *testing.T) {
+	dir := t.TempDir()
+	helpPath := filepath.Join(dir, "mytool.txt")
+	os.WriteFile(helpPath, []byte("  -v, --verbose   be loud\n  --name=NAME      your \"name\"\n"), 0644)
+	outPath := filepath.Join(dir, "mytool.toml")
+
+	var stdout, stderr bytes.Buffer
+	if code := runGen([]string{"-from", helpPath, "-o", outPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr.String())
+	}
+
+	// 生成的配置应当能通过检查
+	cfg, diags := loadConfig(outPath)
+	if len(diags) > 0 {
+		t.Fatalf("generated config has diagnostics:\n%s", diags)
+	}
+	if cfg.Apps[0].Command.Path != "mytool" {
+		t.Errorf("Command.Path = %q, want %q", cfg.Apps[0].Command.Path, "mytool")
+	}
+	items := cfg.Apps[0].Items
+	if len(items) != 2 || items[1].Description != `your "name"` {
+		t.Errorf("items = %+v", items)
+	}
+}
+
+func TestRunGenWriteError(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("requires /dev/full")
+	}
+	helpPath := filepath.Join(t.TempDir(), "mytool.txt")
+	os.WriteFile(helpPath, []byte("  -v, --verbose   be loud\n"), 0644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runGen([]string{"-from", helpPath, "-o", "/dev/full"}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+}
+
+func TestRunGenUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runGen(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+}
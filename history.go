@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 最多保留的历史记录数
+const maxHistory = 500
+
+// 最多保留的输出字节数
+const maxOutputTail = 4096
+
+// 一次执行记录
+type HistoryEntry struct {
+	Time time.Time `json:"time"`
+	App  string    `json:"app"`
+	Path string    `json:"path"`
+	Args []string  `json:"args"`
+	// 记录不完整（密码已替换为 secretMask，或使用了临时文件、标准输入），
+	// 重新执行时需要从表单重建参数
+	Partial  bool              `json:"partial,omitempty"`
+	Env      map[string]string `json:"env,omitempty"`
+	Dir      string            `json:"dir,omitempty"`
+	ExitCode int               `json:"exit_code"`
+	Duration time.Duration     `json:"duration"`
+	Output   string            `json:"output,omitempty"`
+	// 执行结束后释放文件，不保存
+	release func()
+}
+
+func (e *HistoryEntry) CommandLine() string {
+	return quoteCommandLine(e.Path, e.Args)
+}
+
+type historyStore struct {
+	mu        sync.Mutex
+	path      string
+	entries   []HistoryEntry
+	listeners []func()
+}
+
+// 打开配置文件对应的历史记录，dir 或 configPath 为空时返回 nil
+func openHistory(dir, configPath string) *historyStore {
+	if dir == "" || configPath == "" {
+		return nil
+	}
+	h := &historyStore{path: filepath.Join(dir, stateFileBase(configPath)+".history.jsonl")}
+	f, err := os.Open(h.path)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e HistoryEntry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			h.entries = append(h.entries, e)
+		}
+	}
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+		h.rewrite()
+	}
+	return h
+}
+
+func (h *historyStore) Add(e HistoryEntry) error {
+	h.mu.Lock()
+	h.entries = append(h.entries, e)
+	var err error
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+		err = h.rewrite()
+	} else {
+		err = h.append(e)
+	}
+	listeners := append([]func(){}, h.listeners...)
+	h.mu.Unlock()
+	for _, fn := range listeners {
+		fn()
+	}
+	return err
+}
+
+// 按时间倒序返回记录，app 为空时返回全部
+func (h *historyStore) Entries(app string) []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var list []HistoryEntry
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if app == "" || h.entries[i].App == app {
+			list = append(list, h.entries[i])
+		}
+	}
+	return list
+}
+
+// 注册变更通知，返回取消函数
+func (h *historyStore) OnChange(fn func()) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.listeners = append(h.listeners, fn)
+	idx := len(h.listeners) - 1
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.listeners[idx] = func() {}
+	}
+}
+
+func (h *historyStore) append(e HistoryEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func (h *historyStore) rewrite() error {
+	var b strings.Builder
+	for _, e := range h.entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+// 只保留最后 max 字节的输出
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = append([]byte{}, t.buf[len(t.buf)-t.max:]...)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
+
+func (u *AppUI) peer(key string) *AppUI {
+	for _, p := range u.peers {
+		if p.stateKey == key {
+			return p
+		}
+	}
+	if u.stateKey == key {
+		return u
+	}
+	return nil
+}
+
+// 将历史记录的参数填回表单，记录中没有的密码保留当前值
+func (u *AppUI) loadHistoryEntry(e *HistoryEntry) []string {
+	values, unmatched := parseArgs(u.app, e.Args)
+	if e.Partial {
+		for i := range u.app.Items {
+			item := &u.app.Items[i]
+			if item.IsSecret() {
+				values[item.Name] = u.itemValues(item)
+			}
+		}
+	}
+	u.applyValues(values)
+	return unmatched
+}
+
+// 按历史记录重新执行记录中的命令，记录不完整时先填回表单，再使用表单中当前的密码和文本
+func (u *AppUI) rerunHistoryEntry(e *HistoryEntry) ([]string, error) {
+	if !e.Partial {
+		u.run(&runSpec{path: e.Path, args: e.Args, masked: e.Args}, e.Env, e.Dir)
+		return nil, nil
+	}
+	unmatched := u.loadHistoryEntry(e)
+	spec, err := u.prepareRun()
+	if err != nil {
+		return unmatched, err
+	}
+	spec.path = e.Path
+	u.run(spec, e.Env, e.Dir)
+	return unmatched, nil
+}
+
+// 历史记录窗口，filter 为初始筛选的 app
+func (u *AppUI) showHistory(filter string) {
+	const allApps = "All"
+	apps := []string{allApps}
+	for _, p := range u.peers {
+		apps = append(apps, p.stateKey)
+	}
+
+	var entries []HistoryEntry
+	var selected *HistoryEntry
+	detail := widget.NewMultiLineEntry()
+	detail.Wrapping = fyne.TextWrapWord
+
+	list := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			e := entries[id]
+			obj.(*widget.Label).SetText(fmt.Sprintf("%s  %s  [%d]  %s",
+				e.Time.Format("2006-01-02 15:04:05"), e.App, e.ExitCode, e.Duration.Round(time.Millisecond)))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = &entries[id]
+		detail.SetText(formatHistoryEntry(selected))
+	}
+
+	appSel := widget.NewSelect(apps, nil)
+	reload := func() {
+		app := appSel.Selected
+		if app == allApps {
+			app = ""
+		}
+		entries = u.history.Entries(app)
+		selected = nil
+		list.UnselectAll()
+		detail.SetText("")
+		list.Refresh()
+	}
+	appSel.OnChanged = func(string) { reload() }
+	if filter == "" {
+		filter = allApps
+	}
+	appSel.SetSelected(filter)
+
+	win := fyne.CurrentApp().NewWindow("History")
+	rerunBtn := widget.NewButton("Re-run", func() {
+		if selected == nil {
+			return
+		}
+		p := u.peer(selected.App)
+		if p == nil {
+			dialog.ShowError(fmt.Errorf("app %q not found", selected.App), win)
+			return
+		}
+		e := *selected
+		unmatched, err := p.rerunHistoryEntry(&e)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if len(unmatched) > 0 {
+			dialog.ShowInformation("Unmatched Arguments", fmt.Sprintf("These arguments could not be mapped:\n%s", strings.Join(unmatched, "\n")), win)
+		}
+	})
+	loadBtn := widget.NewButton("Load into form", func() {
+		if selected == nil {
+			return
+		}
+		p := u.peer(selected.App)
+		if p == nil {
+			dialog.ShowError(fmt.Errorf("app %q not found", selected.App), win)
+			return
+		}
+		if unmatched := p.loadHistoryEntry(selected); len(unmatched) > 0 {
+			dialog.ShowInformation("Unmatched Arguments", fmt.Sprintf("These arguments could not be mapped:\n%s", strings.Join(unmatched, "\n")), win)
+		}
+	})
+
+	cancel := u.history.OnChange(func() { fyne.Do(reload) })
+	win.SetOnClosed(cancel)
+
+	top := container.NewBorder(nil, nil, widget.NewLabel("App"), nil, appSel)
+	right := container.NewBorder(nil, container.NewHBox(rerunBtn, loadBtn), nil, nil, detail)
+	split := container.NewHSplit(list, right)
+	split.Offset = 0.5
+	win.SetContent(container.NewBorder(top, nil, nil, nil, split))
+	win.Resize(fyne.NewSize(800, 500))
+	win.Show()
+}
+
+func formatHistoryEntry(e *HistoryEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Time: %s\n", e.Time.Format(time.RFC3339))
+	fmt.Fprintf(&b, "App: %s\n", e.App)
+	fmt.Fprintf(&b, "Command: %s\n", e.CommandLine())
+	if e.Dir != "" {
+		fmt.Fprintf(&b, "Directory: %s\n", e.Dir)
+	}
+	for _, k := range slices.Sorted(maps.Keys(e.Env)) {
+		fmt.Fprintf(&b, "Env: %s=%s\n", k, e.Env[k])
+	}
+	fmt.Fprintf(&b, "Exit code: %d\n", e.ExitCode)
+	fmt.Fprintf(&b, "Duration: %s\n", e.Duration.Round(time.Millisecond))
+	if e.Output != "" {
+		fmt.Fprintf(&b, "\n%s", e.Output)
+	}
+	return b.String()
+}
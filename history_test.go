@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestHistoryStorePersist(t *testing.T) {
+	dir := t.TempDir()
+	h := openHistory(dir, "/tmp/tools.toml")
+	h.Add(HistoryEntry{Time: time.Now(), App: "A", Path: "cmd", Args: []string{"1"}})
+	h.Add(HistoryEntry{Time: time.Now(), App: "B", Path: "cmd", Args: []string{"2"}})
+	h.Add(HistoryEntry{Time: time.Now(), App: "A", Path: "cmd", Args: []string{"3"}, ExitCode: 2})
+
+	reopened := openHistory(dir, "/tmp/tools.toml")
+	all := reopened.Entries("")
+	if len(all) != 3 || all[0].Args[0] != "3" {
+		t.Fatalf("Entries() = %+v, want 3 entries newest first", all)
+	}
+	a := reopened.Entries("A")
+	if len(a) != 2 || a[0].ExitCode != 2 || a[1].Args[0] != "1" {
+		t.Errorf("Entries(A) = %+v", a)
+	}
+}
+
+func TestHistoryStoreLimit(t *testing.T) {
+	dir := t.TempDir()
+	h := openHistory(dir, "/tmp/tools.toml")
+	for i := 0; i < maxHistory+5; i++ {
+		h.Add(HistoryEntry{App: "A", Args: []string{fmt.Sprint(i)}})
+	}
+	entries := openHistory(dir, "/tmp/tools.toml").Entries("")
+	if len(entries) != maxHistory {
+		t.Fatalf("len(Entries()) = %d, want %d", len(entries), maxHistory)
+	}
+	if entries[len(entries)-1].Args[0] != "5" {
+		t.Errorf("oldest entry = %v, want 5", entries[len(entries)-1].Args)
+	}
+}
+
+func TestHistoryStoreOnChange(t *testing.T) {
+	h := openHistory(t.TempDir(), "/tmp/tools.toml")
+	calls := 0
+	cancel := h.OnChange(func() { calls++ })
+	h.Add(HistoryEntry{App: "A"})
+	cancel()
+	h.Add(HistoryEntry{App: "A"})
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := newTailBuffer(5)
+	b.Write([]byte("abc"))
+	b.Write([]byte("defg"))
+	if b.String() != "cdefg" {
+		t.Errorf("String() = %q, want %q", b.String(), "cdefg")
+	}
+}
+
+func TestRunRecordsHistory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	app := &App{
+		Command: Command{Path: "sh", Name: "Shell", Output: "realtime-console"},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.setStore(nil, 0)
+	ui.history = openHistory(t.TempDir(), "/tmp/tools.toml")
+	ui.Build()
+
+	args := []string{"-c", "echo hello; exit 3"}
+	ui.run(&runSpec{args: args, masked: args}, map[string]string{"FOO": "bar"}, t.TempDir())
+	ui.jobs.waitAll()
+
+	entries := ui.history.Entries("Shell")
+	if len(entries) != 1 {
+		t.Fatalf("len(Entries()) = %d, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", e.ExitCode)
+	}
+	if strings.TrimSpace(e.Output) != "hello" {
+		t.Errorf("Output = %q, want hello", e.Output)
+	}
+	if e.Env["FOO"] != "bar" || e.Dir == "" {
+		t.Errorf("Env = %v, Dir = %q", e.Env, e.Dir)
+	}
+	if !reflect.DeepEqual(e.Args, []string{"-c", "echo hello; exit 3"}) {
+		t.Errorf("Args = %v", e.Args)
+	}
+}
+
+func TestLoadHistoryEntry(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd", Name: "Tool"},
+		Items: []Item{
+			{Name: "v", Type: "bool", Short: true},
+			{Name: "out", Type: "string", Default: "x"},
+			{Name: "file", Type: "string", Positional: true},
+		},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.Build()
+
+	unmatched := ui.loadHistoryEntry(&HistoryEntry{Args: []string{"-v", "a.txt", "--extra"}})
+	if !reflect.DeepEqual(unmatched, []string{"--extra"}) {
+		t.Errorf("unmatched = %v, want [--extra]", unmatched)
+	}
+	want := []string{"-v", "a.txt"}
+	if got := ui.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if exitCode(nil) != 0 {
+		t.Error("exitCode(nil) != 0")
+	}
+	if exitCode(errDetached) != -1 {
+		t.Error("exitCode(errDetached) != -1")
+	}
+}
+
+func TestRunMasksSecrets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	app := &App{
+		Command: Command{Path: "sh", Name: "Shell", Args: []string{"-c", "exit 0"}, Output: "realtime-console"},
+		Items: []Item{
+			{Name: "user", Type: "string", Short: true, Separator: " "},
+			{Name: "token", Type: "password", Separator: " "},
+		},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.setStore(nil, 0)
+	ui.history = openHistory(t.TempDir(), "/tmp/tools.toml")
+	ui.Build()
+
+	setEntryText(ui.widgets["user"], "alice")
+	setEntryText(ui.widgets["token"], "s3cret")
+	ui.Execute()
+	ui.jobs.waitAll()
+
+	entries := ui.history.Entries("Shell")
+	if len(entries) != 1 {
+		t.Fatalf("len(Entries()) = %d, want 1", len(entries))
+	}
+	e := entries[0]
+	want := []string{"-c", "exit 0", "-user", "alice", "--token", "****"}
+	if !reflect.DeepEqual(e.Args, want) || !e.Partial {
+		t.Errorf("history entry = %v (partial %v), want %v", e.Args, e.Partial, want)
+	}
+	data, err := os.ReadFile(ui.history.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cret") {
+		t.Error("history file should not contain the secret")
+	}
+
+	// 填回表单时保留当前的密码
+	setEntryText(ui.widgets["user"], "bob")
+	ui.loadHistoryEntry(&e)
+	if got := ui.widgets["user"].(*widget.Entry).Text; got != "alice" {
+		t.Errorf("user = %q, want alice", got)
+	}
+	if got := ui.widgets["token"].(*widget.Entry).Text; got != "s3cret" {
+		t.Errorf("token = %q, want s3cret", got)
+	}
+}
+
+func TestRerunUsesRecordedPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	app := &App{
+		Command: Command{Path: "sh", Name: "Shell", Output: "realtime-console"},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.setStore(nil, 0)
+	ui.history = openHistory(t.TempDir(), "/tmp/tools.toml")
+	ui.Build()
+
+	args := []string{"-c", "exit 3"}
+	ui.run(&runSpec{args: args, masked: args}, nil, t.TempDir())
+	ui.jobs.waitAll()
+
+	// 配置修改后重新执行记录中的命令
+	app.Command.Path = "cliface-missing-command"
+	e := ui.history.Entries("Shell")[0]
+	if _, err := ui.rerunHistoryEntry(&e); err != nil {
+		t.Fatal(err)
+	}
+	ui.jobs.waitAll()
+
+	entries := ui.history.Entries("Shell")
+	if len(entries) != 2 {
+		t.Fatalf("len(Entries()) = %d, want 2", len(entries))
+	}
+	if entries[0].Path != "sh" || entries[0].ExitCode != 3 {
+		t.Errorf("rerun Path = %q, ExitCode = %d, want sh, 3", entries[0].Path, entries[0].ExitCode)
+	}
+}
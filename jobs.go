@@ -0,0 +1,573 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 任务输出最多保留的字节数
+const maxJobOutput = 64 * 1024
+
+// 最多保留的已结束任务数，超出时移除最早结束的
+const maxFinishedJobs = 100
+
+// 任务状态
+const (
+	jobQueued   = "queued"
+	jobRunning  = "running"
+	jobDone     = "done"
+	jobFailed   = "failed"
+	jobCanceled = "canceled"
+	// visible 模式启动后独立运行，关闭窗口时不结束也不等待
+	jobDetached = "detached"
+)
+
+var errJobCanceled = errors.New("job canceled")
+
+// 一次执行，从排队到结束
+type Job struct {
+	ID   int
+	App  string
+	Path string
+	// 隐藏密码后的参数
+	Args     []string
+	Queued   time.Time
+	Start    time.Time
+	State    string
+	ExitCode int
+	Duration time.Duration
+	// 进程如何结束，如 exited with code 0
+	Result string
+
+	cmd    *exec.Cmd
+	output *tailBuffer
+	// 该 app 同时执行的上限，0 为不限
+	limit int
+	// 用于重新执行
+	ui    *AppUI
+	entry HistoryEntry
+	// 取消时的信号和升级为 SIGKILL 前的等待时间
+	stopSignal  string
+	stopTimeout time.Duration
+	// 取消时做了什么，如 sent SIGTERM
+	stop string
+	// finish 后关闭
+	done chan struct{}
+}
+
+func (j *Job) CommandLine() string {
+	return quoteCommandLine(j.Path, j.Args)
+}
+
+// 任务是否还未结束
+func (j *Job) active() bool {
+	return j.State == jobQueued || j.State == jobRunning
+}
+
+// 之后还会登记任务的来源，如批量执行中等待的输入
+type jobSource interface {
+	// 还未登记为任务的数量
+	pending() int
+	// 不再登记新任务
+	stop()
+}
+
+// 记录所有执行中的命令，超出并发上限的任务排队等待
+type jobManager struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// 同时执行的上限，0 为不限
+	limit     int
+	nextID    int
+	jobs      []*Job
+	sources   []jobSource
+	listeners []func()
+}
+
+func newJobManager(limit int) *jobManager {
+	m := &jobManager{limit: limit}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+// 登记一个排队中的任务
+func (m *jobManager) add(u *AppUI, cmd *exec.Cmd, entry *HistoryEntry) *Job {
+	m.mu.Lock()
+	m.nextID++
+	j := &Job{
+		ID:     m.nextID,
+		App:    entry.App,
+		Path:   entry.Path,
+		Args:   entry.Args,
+		Queued: time.Now(),
+		State:  jobQueued,
+		cmd:    cmd,
+		output: newTailBuffer(maxJobOutput),
+		limit:  u.app.Command.MaxJobs,
+		ui:     u,
+		entry:  *entry,
+		done:   make(chan struct{}),
+	}
+	j.entry.release = nil
+	j.stopSignal, j.stopTimeout = u.app.Command.stopSettings()
+	m.jobs = append(m.jobs, j)
+	m.mu.Unlock()
+	m.notify()
+	return j
+}
+
+// 全局和 app 的上限是否允许任务开始，需要持有锁
+func (m *jobManager) allowed(j *Job) bool {
+	total, app := 0, 0
+	for _, r := range m.jobs {
+		if r.State != jobRunning && r.State != jobDetached {
+			continue
+		}
+		total++
+		if r.App == j.App {
+			app++
+		}
+	}
+	return (m.limit <= 0 || total < m.limit) && (j.limit <= 0 || app < j.limit)
+}
+
+// 任务能否开始: 上限允许，且之前排队的任务都不能开始，需要持有锁
+func (m *jobManager) ready(j *Job) bool {
+	if !m.allowed(j) {
+		return false
+	}
+	for _, q := range m.jobs {
+		if q == j {
+			return true
+		}
+		if q.State == jobQueued && m.allowed(q) {
+			return false
+		}
+	}
+	return true
+}
+
+// 排队等待后启动命令，排队时被取消返回 errJobCanceled。
+// 无论是否启动，调用者记录结果后都要调用 finish
+func (m *jobManager) start(j *Job) error {
+	m.mu.Lock()
+	for j.State == jobQueued && !m.ready(j) {
+		m.cond.Wait()
+	}
+	if j.State != jobQueued {
+		m.mu.Unlock()
+		return errJobCanceled
+	}
+	// 持有锁启动，避免启动期间被取消时进程还不存在
+	err := j.cmd.Start()
+	if err == nil {
+		j.State = jobRunning
+		j.Start = time.Now()
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	m.notify()
+	return nil
+}
+
+// 记录任务结束并让出位置，应在记录历史之后调用，等待任务时历史已完整
+func (m *jobManager) finish(j *Job, err error) {
+	m.mu.Lock()
+	if !j.Start.IsZero() {
+		j.Duration = time.Since(j.Start)
+	}
+	j.ExitCode = exitCode(err)
+	j.Result = describeExit(err)
+	switch {
+	case j.State == jobCanceled && j.Start.IsZero():
+		j.Result = "canceled before start"
+	case j.State == jobCanceled:
+		j.Result = "canceled: " + j.stop + "; " + j.Result
+	case err == nil:
+		j.State = jobDone
+	default:
+		j.State = jobFailed
+	}
+	close(j.done)
+	m.prune()
+	m.cond.Broadcast()
+	m.mu.Unlock()
+	m.notify()
+}
+
+// 已结束的任务超过 maxFinishedJobs 时移除最早的，需要持有锁
+func (m *jobManager) prune() {
+	extra := -maxFinishedJobs
+	for _, j := range m.jobs {
+		if !j.active() {
+			extra++
+		}
+	}
+	if extra <= 0 {
+		return
+	}
+	m.jobs = slices.DeleteFunc(slices.Clone(m.jobs), func(j *Job) bool {
+		if extra > 0 && !j.active() {
+			extra--
+			return true
+		}
+		return false
+	})
+}
+
+// 登记任务来源，来源不再登记任务后调用返回的函数
+func (m *jobManager) register(s jobSource) func() {
+	m.mu.Lock()
+	m.sources = append(m.sources, s)
+	m.mu.Unlock()
+	return func() {
+		m.mu.Lock()
+		if i := slices.Index(m.sources, s); i >= 0 {
+			m.sources = slices.Delete(m.sources, i, i+1)
+		}
+		m.cond.Broadcast()
+		m.mu.Unlock()
+		m.notify()
+	}
+}
+
+// 取消任务: 排队中的不再执行，执行中的先发送 stop_signal，超时后结束整个进程组。
+// 再次取消还未结束的任务时立即结束
+func (m *jobManager) cancel(j *Job) {
+	m.mu.Lock()
+	switch j.State {
+	case jobQueued:
+		j.State = jobCanceled
+		m.cond.Broadcast()
+	case jobRunning, jobDetached:
+		j.State = jobCanceled
+		p := j.cmd.Process
+		if j.stopSignal == "SIGKILL" || signalGroup(p, j.stopSignal) != nil {
+			killGroup(p)
+			j.stop = "killed"
+		} else {
+			j.stop = "sent " + j.stopSignal
+			go m.escalate(j)
+		}
+	case jobCanceled:
+		if j.cmd.Process != nil && j.Result == "" {
+			j.stop += ", killed"
+			killGroup(j.cmd.Process)
+		}
+	}
+	m.mu.Unlock()
+	m.notify()
+}
+
+// 标记为独立运行，仍占用并发上限直到进程退出
+func (m *jobManager) detach(j *Job) {
+	m.mu.Lock()
+	if j.State == jobRunning {
+		j.State = jobDetached
+	}
+	m.cond.Broadcast()
+	m.mu.Unlock()
+	m.notify()
+}
+
+// 超过 stop_timeout 仍未结束时结束整个进程组
+func (m *jobManager) escalate(j *Job) {
+	timer := time.NewTimer(j.stopTimeout)
+	defer timer.Stop()
+	select {
+	case <-j.done:
+	case <-timer.C:
+		m.mu.Lock()
+		if j.Result == "" && j.stop == "sent "+j.stopSignal {
+			killGroup(j.cmd.Process)
+			j.stop = fmt.Sprintf("sent %s, killed after %s", j.stopSignal, j.stopTimeout)
+		}
+		m.mu.Unlock()
+		m.notify()
+	}
+}
+
+// 取消所有任务，先停止来源以免之后再登记新任务
+func (m *jobManager) cancelAll() {
+	m.mu.Lock()
+	sources := slices.Clone(m.sources)
+	m.mu.Unlock()
+	for _, s := range sources {
+		s.stop()
+	}
+	for _, j := range m.Jobs() {
+		if m.state(j) != jobDetached {
+			m.cancel(j)
+		}
+	}
+}
+
+func (m *jobManager) state(j *Job) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return j.State
+}
+
+// 进程如何结束，任务结束后才有值
+func (m *jobManager) result(j *Job) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return j.Result
+}
+
+// 按登记顺序返回所有任务
+func (m *jobManager) Jobs() []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Job{}, m.jobs...)
+}
+
+// 排队和执行中的任务数，包括来源中还未登记的
+func (m *jobManager) activeCount() int {
+	m.mu.Lock()
+	n := 0
+	for _, j := range m.jobs {
+		if j.active() {
+			n++
+		}
+	}
+	sources := slices.Clone(m.sources)
+	m.mu.Unlock()
+	// 来源可能在持有自己的锁时取消任务，不能在持有 m.mu 时调用
+	for _, s := range sources {
+		n += s.pending()
+	}
+	return n
+}
+
+// 移除已结束的任务
+func (m *jobManager) clearFinished() {
+	m.mu.Lock()
+	var jobs []*Job
+	for _, j := range m.jobs {
+		if j.active() {
+			jobs = append(jobs, j)
+		}
+	}
+	m.jobs = jobs
+	m.mu.Unlock()
+	m.notify()
+}
+
+// 等待所有任务和来源结束
+func (m *jobManager) waitAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		active := len(m.sources) > 0
+		for _, j := range m.jobs {
+			active = active || j.active()
+		}
+		if !active {
+			return
+		}
+		m.cond.Wait()
+	}
+}
+
+// 注册变更通知，返回取消函数
+func (m *jobManager) OnChange(fn func()) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
+	idx := len(m.listeners) - 1
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.listeners[idx] = func() {}
+	}
+}
+
+func (m *jobManager) notify() {
+	m.mu.Lock()
+	listeners := append([]func(){}, m.listeners...)
+	m.mu.Unlock()
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// 一行的显示文本
+func (j *Job) summary() string {
+	s := fmt.Sprintf("#%d  [%s]  %s  %s", j.ID, j.State, j.App, j.Queued.Format("15:04:05"))
+	switch j.State {
+	case jobRunning, jobDetached:
+		s += "  " + time.Since(j.Start).Round(time.Second).String()
+	case jobDone, jobFailed:
+		s += fmt.Sprintf("  exit %d  %s", j.ExitCode, j.Duration.Round(100*time.Millisecond))
+	}
+	return s
+}
+
+// 重新执行任务，执行中的先取消
+func (m *jobManager) restart(j *Job) ([]string, error) {
+	if s := m.state(j); s == jobQueued || s == jobRunning {
+		m.cancel(j)
+	}
+	e := j.entry
+	return j.ui.rerunHistoryEntry(&e)
+}
+
+// 任务窗口
+func (u *AppUI) showJobs() {
+	m := u.jobs
+	var jobs []*Job
+	var selected *Job
+	detail := widget.NewMultiLineEntry()
+	detail.Wrapping = fyne.TextWrapWord
+
+	list := widget.NewList(
+		func() int { return len(jobs) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			m.mu.Lock()
+			text := jobs[id].summary()
+			m.mu.Unlock()
+			obj.(*widget.Label).SetText(text)
+		},
+	)
+	showDetail := func() {
+		if selected == nil {
+			detail.SetText("")
+			return
+		}
+		m.mu.Lock()
+		text := fmt.Sprintf("App: %s\nCommand: %s\nState: %s\n", selected.App, selected.CommandLine(), selected.State)
+		if selected.Result != "" {
+			text += fmt.Sprintf("Result: %s\n", selected.Result)
+		}
+		m.mu.Unlock()
+		detail.SetText(text)
+	}
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = jobs[id]
+		showDetail()
+	}
+	reload := func() {
+		// 新任务在前
+		all := m.Jobs()
+		jobs = jobs[:0]
+		for i := len(all) - 1; i >= 0; i-- {
+			jobs = append(jobs, all[i])
+		}
+		list.Refresh()
+		showDetail()
+	}
+
+	win := fyne.CurrentApp().NewWindow("Jobs")
+	cancelBtn := widget.NewButton("Cancel", func() {
+		if selected != nil {
+			m.cancel(selected)
+		}
+	})
+	cancelBtn.Importance = widget.DangerImportance
+	restartBtn := widget.NewButton("Restart", func() {
+		if selected == nil {
+			return
+		}
+		unmatched, err := m.restart(selected)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if len(unmatched) > 0 {
+			dialog.ShowInformation("Unmatched Arguments", fmt.Sprintf("These arguments could not be mapped:\n%s", strings.Join(unmatched, "\n")), win)
+		}
+	})
+	outputBtn := widget.NewButton("Open Output", func() {
+		if selected != nil {
+			showJobOutput(m, selected)
+		}
+	})
+	clearBtn := widget.NewButton("Clear Finished", func() {
+		m.clearFinished()
+		selected = nil
+		list.UnselectAll()
+	})
+
+	remove := m.OnChange(func() { fyne.Do(reload) })
+	win.SetOnClosed(remove)
+	reload()
+
+	buttons := container.NewHBox(cancelBtn, restartBtn, outputBtn, clearBtn)
+	split := container.NewVSplit(list, detail)
+	split.Offset = 0.7
+	win.SetContent(container.NewBorder(nil, buttons, nil, nil, split))
+	win.Resize(fyne.NewSize(700, 450))
+	win.Show()
+}
+
+// 任务输出窗口，执行中定时刷新
+func showJobOutput(m *jobManager, j *Job) {
+	out := widget.NewMultiLineEntry()
+	out.Wrapping = fyne.TextWrapWord
+	out.SetText(j.output.String())
+	win := fyne.CurrentApp().NewWindow(fmt.Sprintf("Output #%d", j.ID))
+	done := make(chan struct{})
+	win.SetOnClosed(func() { close(done) })
+	go func() {
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				state := m.state(j)
+				text := j.output.String()
+				fyne.Do(func() {
+					if out.Text != text {
+						out.SetText(text)
+					}
+				})
+				if state != jobQueued && state != jobRunning {
+					return
+				}
+			}
+		}
+	}()
+	win.SetContent(container.NewScroll(out))
+	win.Resize(fyne.NewSize(600, 400))
+	win.Show()
+}
+
+// 主窗口关闭时，仍有任务则先确认，再结束这些任务并等待进程退出
+func interceptClose(w fyne.Window, m *jobManager) {
+	w.SetCloseIntercept(func() {
+		n := m.activeCount()
+		if n == 0 {
+			w.Close()
+			return
+		}
+		msg := fmt.Sprintf("%d job(s) still running or queued. Stop them and quit?", n)
+		dialog.ShowConfirm("Jobs Running", msg, func(ok bool) {
+			if !ok {
+				return
+			}
+			prog := dialog.NewCustomWithoutButtons("Stopping jobs...", widget.NewProgressBarInfinite(), w)
+			prog.Show()
+			m.cancelAll()
+			go func() {
+				m.waitAll()
+				fyne.Do(w.Close)
+			}()
+		}, w)
+	})
+}
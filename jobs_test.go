@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+)
+
+// 创建 app 的任务，执行 sh -c script
+func addShellJob(m *jobManager, u *AppUI, script string) *Job {
+	spec := &runSpec{args: []string{"-c", script}, masked: []string{"-c", script}}
+	j := m.add(u, u.newCommand(spec, nil, ""), u.newHistoryEntry(spec, nil, ""))
+	j.cmd.Stdout = j.output
+	j.cmd.Stderr = j.output
+	return j
+}
+
+func newShellUI(key string, maxJobs int) *AppUI {
+	u := NewAppUI(&App{Command: Command{Path: "sh", MaxJobs: maxJobs}}, test.NewWindow(nil))
+	u.stateKey = key
+	return u
+}
+
+// 在后台启动任务，返回 start 的结果
+func startAsync(m *jobManager, j *Job) chan error {
+	ch := make(chan error, 1)
+	go func() { ch <- m.start(j) }()
+	return ch
+}
+
+func waitState(t *testing.T, m *jobManager, j *Job, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for m.state(j) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("job #%d state = %s, want %s", j.ID, m.state(j), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// 结束执行中的任务
+func stopJob(m *jobManager, j *Job) {
+	m.cancel(j)
+	m.finish(j, j.cmd.Wait())
+}
+
+func TestJobManagerQueue(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	m := newJobManager(1)
+	u := newShellUI("a", 0)
+
+	first := addShellJob(m, u, "sleep 10")
+	if err := m.start(first); err != nil {
+		t.Fatal(err)
+	}
+	second := addShellJob(m, u, "exit 0")
+	started := startAsync(m, second)
+	time.Sleep(50 * time.Millisecond)
+	if s := m.state(second); s != jobQueued {
+		t.Fatalf("second state = %s, want queued", s)
+	}
+	if n := m.activeCount(); n != 2 {
+		t.Errorf("activeCount() = %d, want 2", n)
+	}
+
+	stopJob(m, first)
+	if err := <-started; err != nil {
+		t.Fatal(err)
+	}
+	m.finish(second, second.cmd.Wait())
+	m.waitAll()
+	if first.State != jobCanceled || second.State != jobDone {
+		t.Errorf("states = %s, %s, want canceled, done", first.State, second.State)
+	}
+}
+
+func TestJobManagerCancelQueued(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	m := newJobManager(1)
+	u := newShellUI("a", 0)
+
+	first := addShellJob(m, u, "sleep 10")
+	if err := m.start(first); err != nil {
+		t.Fatal(err)
+	}
+	second := addShellJob(m, u, "exit 0")
+	started := startAsync(m, second)
+	waitState(t, m, second, jobQueued)
+	m.cancel(second)
+	if err := <-started; !errors.Is(err, errJobCanceled) {
+		t.Errorf("start() = %v, want errJobCanceled", err)
+	}
+	if second.cmd.Process != nil {
+		t.Error("canceled job was started")
+	}
+	m.finish(second, errJobCanceled)
+	stopJob(m, first)
+	m.waitAll()
+}
+
+func TestJobManagerAppLimit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	m := newJobManager(0)
+	a := newShellUI("a", 1)
+	b := newShellUI("b", 0)
+
+	running := addShellJob(m, a, "sleep 10")
+	if err := m.start(running); err != nil {
+		t.Fatal(err)
+	}
+	queued := addShellJob(m, a, "exit 0")
+	started := startAsync(m, queued)
+	waitState(t, m, queued, jobQueued)
+
+	// 其他 app 不受该 app 上限影响，也不等待排在前面的任务
+	other := addShellJob(m, b, "exit 0")
+	if err := m.start(other); err != nil {
+		t.Fatal(err)
+	}
+	m.finish(other, other.cmd.Wait())
+	if s := m.state(queued); s != jobQueued {
+		t.Errorf("queued state = %s, want queued", s)
+	}
+
+	stopJob(m, running)
+	if err := <-started; err != nil {
+		t.Fatal(err)
+	}
+	m.finish(queued, queued.cmd.Wait())
+	m.waitAll()
+
+	m.clearFinished()
+	if jobs := m.Jobs(); len(jobs) != 0 {
+		t.Errorf("Jobs() after clear = %d, want 0", len(jobs))
+	}
+}
+
+func TestStopSettings(t *testing.T) {
+	tests := []struct {
+		signal, timeout string
+		wantSignal      string
+		wantTimeout     time.Duration
+	}{
+		{"", "", "SIGTERM", 5 * time.Second},
+		{"int", "2s", "SIGINT", 2 * time.Second},
+		{"SIGHUP", "0s", "SIGHUP", 0},
+		{"SIGFOO", "soon", "SIGTERM", 5 * time.Second},
+	}
+	for _, tt := range tests {
+		c := &Command{StopSignal: tt.signal, StopTimeout: tt.timeout}
+		sig, timeout := c.stopSettings()
+		if sig != tt.wantSignal || timeout != tt.wantTimeout {
+			t.Errorf("stopSettings(%q, %q) = %s, %s, want %s, %s", tt.signal, tt.timeout, sig, timeout, tt.wantSignal, tt.wantTimeout)
+		}
+	}
+}
+
+// 等待任务结束并返回结果，超时则失败
+func waitJob(t *testing.T, m *jobManager, j *Job) string {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- j.cmd.Wait() }()
+	select {
+	case err := <-done:
+		m.finish(j, err)
+	case <-time.After(5 * time.Second):
+		killGroup(j.cmd.Process)
+		t.Fatal("job did not stop")
+	}
+	return m.result(j)
+}
+
+func TestJobCancelStopsProcessGroup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	m := newJobManager(0)
+	u := newShellUI("a", 0)
+
+	// 后台的 sleep 持有输出管道，只结束 sh 时 Wait 不会返回
+	j := addShellJob(m, u, "sleep 30 & echo ready; wait")
+	if err := m.start(j); err != nil {
+		t.Fatal(err)
+	}
+	waitOutput(t, j, "ready")
+	m.cancel(j)
+	if got := waitJob(t, m, j); !strings.HasPrefix(got, "canceled: sent SIGTERM; ended by signal") {
+		t.Errorf("result = %q", got)
+	}
+
+	j = addShellJob(m, u, "exit 3")
+	if err := m.start(j); err != nil {
+		t.Fatal(err)
+	}
+	if got := waitJob(t, m, j); got != "exited with code 3" {
+		t.Errorf("result = %q, want exited with code 3", got)
+	}
+}
+
+func TestJobCancelEscalates(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	m := newJobManager(0)
+	u := newShellUI("a", 0)
+	u.app.Command.StopTimeout = "100ms"
+
+	j := addShellJob(m, u, "trap '' TERM; echo ready; sleep 30")
+	if err := m.start(j); err != nil {
+		t.Fatal(err)
+	}
+	waitOutput(t, j, "ready")
+	m.cancel(j)
+	got := waitJob(t, m, j)
+	if want := "canceled: sent SIGTERM, killed after 100ms; ended by signal: killed"; got != want {
+		t.Errorf("result = %q, want %q", got, want)
+	}
+}
+
+func waitOutput(t *testing.T, j *Job, text string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(j.output.String(), text) {
+		if time.Now().After(deadline) {
+			t.Fatalf("output %q does not contain %q", j.output.String(), text)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobManagerPrune(t *testing.T) {
+	m := newJobManager(0)
+	u := newShellUI("a", 0)
+	queued := addShellJob(m, u, "exit 0")
+	var finished []*Job
+	for range maxFinishedJobs + 5 {
+		j := addShellJob(m, u, "exit 0")
+		m.cancel(j)
+		m.finish(j, errJobCanceled)
+		finished = append(finished, j)
+	}
+	jobs := m.Jobs()
+	if len(jobs) != maxFinishedJobs+1 {
+		t.Fatalf("len(Jobs()) = %d, want %d", len(jobs), maxFinishedJobs+1)
+	}
+	// 只移除最早结束的任务，未结束的保留
+	if jobs[0] != queued || jobs[1] != finished[5] {
+		t.Errorf("Jobs() starts with #%d, #%d, want #%d, #%d", jobs[0].ID, jobs[1].ID, queued.ID, finished[5].ID)
+	}
+}
+
+func TestVisibleRunDetached(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("visible mode uses a launcher on this platform")
+	}
+	app := &App{Command: Command{Path: "sh", Name: "Shell", Args: []string{"-c", "sleep 10"}, Mode: "visible"}}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.setStore(nil, 0)
+	ui.history = openHistory(t.TempDir(), "/tmp/tools.toml")
+	ui.Build()
+
+	ui.Execute()
+	jobs := ui.jobs.Jobs()
+	if len(jobs) != 1 {
+		t.Fatalf("len(Jobs()) = %d, want 1", len(jobs))
+	}
+	j := jobs[0]
+	waitState(t, ui.jobs, j, jobDetached)
+	if entries := ui.history.Entries("Shell"); len(entries) != 1 || entries[0].ExitCode != -1 {
+		t.Errorf("history = %+v, want one entry with exit code -1", entries)
+	}
+	// 关闭窗口时不等待也不结束独立运行的任务
+	if n := ui.jobs.activeCount(); n != 0 {
+		t.Errorf("activeCount() = %d, want 0", n)
+	}
+	ui.jobs.cancelAll()
+	ui.jobs.waitAll()
+	if s := ui.jobs.state(j); s != jobDetached {
+		t.Errorf("state after cancelAll = %s, want detached", s)
+	}
+
+	ui.jobs.cancel(j)
+	<-j.done
+	if j.State != jobCanceled {
+		t.Errorf("state = %s, want canceled", j.State)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// cliface lint [-format text|json] [-strict] file...
+func runLint(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "text", "output format: text or json")
+	strict := fs.Bool("strict", false, "treat warnings as errors")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: cliface lint [-format text|json] [-strict] file...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 || (*format != "text" && *format != "json") {
+		fs.Usage()
+		return 2
+	}
+
+	all := Diagnostics{}
+	for _, path := range fs.Args() {
+		_, diags := loadConfig(path)
+		all = append(all, diags...)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(all)
+	} else if len(all) > 0 {
+		fmt.Fprintln(stdout, all.String())
+	}
+
+	if all.HasErrors() || (*strict && len(all) > 0) {
+		return 1
+	}
+	return 0
+}
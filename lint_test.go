@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunLintValid(t *testing.T) {
+	path := writeTempFile(t, `
+[[apps]]
+[apps.command]
+path = "echo"
+
+[[apps.items]]
+name = "msg"
+type = "string"
+`)
+	var stdout, stderr bytes.Buffer
+	code := runLint([]string{path}, &stdout, &stderr)
+	if code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("stdout = %q, want empty", stdout.String())
+	}
+}
+
+func TestRunLintText(t *testing.T) {
+	path := writeTempFile(t, `
+[[apps]]
+[apps.command]
+path = "echo"
+output = "popup"
+`)
+	var stdout, stderr bytes.Buffer
+	code := runLint([]string{path}, &stdout, &stderr)
+	if code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	want := path + ":5: error: apps[0].command.output: unknown output \"popup\""
+	if strings.TrimSpace(stdout.String()) != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunLintJSON(t *testing.T) {
+	path := writeTempFile(t, `
+[[apps]]
+[apps.command]
+path = "echo"
+typo = 1
+`)
+	var stdout, stderr bytes.Buffer
+	code := runLint([]string{"-format", "json", path}, &stdout, &stderr)
+	if code != 0 {
+		t.Errorf("exit code = %d, want 0 (warnings only)", code)
+	}
+	var diags []Diagnostic
+	if err := json.Unmarshal(stdout.Bytes(), &diags); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, stdout.String())
+	}
+	if len(diags) != 1 || diags[0].Severity != SeverityWarning || diags[0].Line != 5 {
+		t.Errorf("diags = %+v, want one warning on line 5", diags)
+	}
+
+	// -strict 时警告也视为失败
+	stdout.Reset()
+	code = runLint([]string{"-strict", "-format", "json", path}, &stdout, &stderr)
+	if code != 1 {
+		t.Errorf("strict exit code = %d, want 1", code)
+	}
+}
+
+func TestRunLintJSONEmpty(t *testing.T) {
+	path := writeTempFile(t, `
+[[apps]]
+[apps.command]
+path = "echo"
+`)
+	var stdout, stderr bytes.Buffer
+	runLint([]string{"-format=json", path}, &stdout, &stderr)
+	if strings.TrimSpace(stdout.String()) != "[]" {
+		t.Errorf("stdout = %q, want []", stdout.String())
+	}
+}
+
+func TestRunLintUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runLint(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("no files: exit code = %d, want 2", code)
+	}
+	if code := runLint([]string{"-format", "xml", "a.toml"}, &stdout, &stderr); code != 2 {
+		t.Errorf("bad format: exit code = %d, want 2", code)
+	}
+}
+
+func TestRunLintExamples(t *testing.T) {
+	files, _ := filepath.Glob("examples/*.toml")
+	if len(files) == 0 {
+		t.Skip("no examples")
+	}
+	// 示例要能通过 CI 中的 -strict 检查
+	var stdout, stderr bytes.Buffer
+	if code := runLint(append([]string{"-strict"}, files...), &stdout, &stderr); code != 0 {
+		t.Errorf("exit code = %d, want 0:\n%s", code, stdout.String())
+	}
+}
@@ -1,16 +1,31 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
 	"github.com/BurntSushi/toml"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "lint", "check":
+			os.Exit(runLint(os.Args[2:], os.Stdout, os.Stderr))
+		case "gen":
+			os.Exit(runGen(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+
 	configPath := flag.String("c", "", "config file path")
 	flag.StringVar(configPath, "config", "", "config file path")
 	flag.Parse()
@@ -20,9 +35,20 @@ func main() {
 		*configPath = filepath.Join(filepath.Dir(exe), "config.toml")
 	}
 
-	cfg := loadConfig(*configPath)
+	cfg, diags := loadConfig(*configPath)
+	if len(diags) > 0 {
+		fmt.Fprintln(os.Stderr, diags.String())
+	}
+
+	if diags.HasErrors() {
+		if hasDisplay() {
+			showDiagnostics(app.New(), diags)
+		}
+		os.Exit(1)
+	}
 
 	a := app.New()
+
 	title := cfg.Title
 	if title == "" {
 		if len(cfg.Apps) == 1 {
@@ -37,10 +63,26 @@ func main() {
 	w.ShowAndRun()
 }
 
-func loadConfig(path string) *Config {
+// 加载配置并做语义检查，返回的 cfg 在有错误时也可能部分可用
+func loadConfig(path string) (*Config, Diagnostics) {
 	var cfg Config
-	if _, err := toml.DecodeFile(path, &cfg); err != nil {
-		panic(err)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return &cfg, Diagnostics{{File: path, Severity: SeverityError, Message: err.Error()}}
+	}
+	md, err := toml.Decode(string(data), &cfg)
+	if err != nil {
+		d := Diagnostic{File: path, Severity: SeverityError, Message: err.Error()}
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			d.Line = perr.Position.Line
+			d.Message = perr.Message
+		}
+		return &cfg, Diagnostics{d}
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		cfg.Path = abs
 	}
 	for i := range cfg.Apps {
 		if cfg.Apps[i].Command.Mode == "" {
@@ -56,5 +98,34 @@ func loadConfig(path string) *Config {
 	if cfg.Height == 0 {
 		cfg.Height = 300
 	}
-	return &cfg
+
+	c := &configChecker{file: path, lines: indexKeyLines(string(data))}
+	var undecoded []string
+	for _, k := range md.Undecoded() {
+		undecoded = append(undecoded, k.String())
+	}
+	c.checkUndecoded(undecoded)
+	c.checkConfig(&cfg)
+	sort.SliceStable(c.diags, func(i, j int) bool { return c.diags[i].Line < c.diags[j].Line })
+	return &cfg, c.diags
+}
+
+// 是否有可用的图形界面
+func hasDisplay() bool {
+	if runtime.GOOS != "linux" && runtime.GOOS != "freebsd" {
+		return true
+	}
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// 显示配置错误窗口
+func showDiagnostics(a fyne.App, diags Diagnostics) {
+	w := a.NewWindow("Config Error")
+	entry := widget.NewMultiLineEntry()
+	entry.SetText(diags.String())
+	entry.Wrapping = fyne.TextWrapWord
+	closeBtn := widget.NewButton("Close", func() { a.Quit() })
+	w.SetContent(container.NewBorder(nil, closeBtn, nil, nil, container.NewScroll(entry)))
+	w.Resize(fyne.NewSize(600, 300))
+	w.ShowAndRun()
 }
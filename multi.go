@@ -0,0 +1,266 @@
+package main
+
+import (
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 有多个值的控件
+type valuesWidget interface {
+	Values() []string
+	SetValues(vals []string)
+}
+
+// 多值字段，每行使用与单值字段相同的控件
+type multiWidget struct {
+	widget.BaseWidget
+	vbox   *fyne.Container
+	rows   []fyne.CanvasObject
+	addBtn *widget.Button
+	// 创建一行控件，读取和设置一行的值
+	newRow   func() fyne.CanvasObject
+	rowValue func(w fyne.CanvasObject) string
+	setRow   func(w fyne.CanvasObject, val string)
+	// 行数范围，maxCount 为 0 时不限制
+	minRows  int
+	maxCount int
+	disabled bool
+	// 值变化回调，用于条件监听
+	onChanged func()
+}
+
+func (u *AppUI) createMultiWidget(item *Item) fyne.CanvasObject {
+	// 每行按单值字段创建，默认值只用于初始行，不用于新行
+	single := *item
+	single.Multi = false
+	single.Default = nil
+
+	mw := &multiWidget{
+		vbox:     container.NewVBox(),
+		minRows:  max(1, item.MinCount),
+		maxCount: item.MaxCount,
+	}
+	mw.newRow = func() fyne.CanvasObject {
+		w := u.createWidget(&single)
+		onWidgetChanged(w, mw.changed)
+		return w
+	}
+	mw.rowValue = func(w fyne.CanvasObject) string { return u.getWidgetValue(&single, w) }
+	mw.setRow = func(w fyne.CanvasObject, val string) { u.setWidgetValues(&single, w, []string{val}) }
+	mw.addBtn = widget.NewButton("+", func() {
+		mw.addRow()
+		mw.changed()
+	})
+	mw.SetValues(item.DefaultValues())
+	mw.ExtendBaseWidget(mw)
+	return mw
+}
+
+func (m *multiWidget) changed() {
+	if m.onChanged != nil {
+		m.onChanged()
+	}
+}
+
+// 添加一个空行，返回该行的控件
+func (m *multiWidget) addRow() fyne.CanvasObject {
+	w := m.newRow()
+	m.rows = append(m.rows, w)
+	m.layoutRows()
+	return w
+}
+
+// 从某一行开始依次填入多个值，其余的值插入为新行，不超过 max_count
+func (m *multiWidget) fillFrom(row fyne.CanvasObject, vals []string) {
+	i := slices.Index(m.rows, row)
+	if i < 0 || len(vals) == 0 {
+		return
+	}
+	m.setRow(row, vals[0])
+	for _, v := range vals[1:] {
+		if m.maxCount > 0 && len(m.rows) >= m.maxCount {
+			break
+		}
+		w := m.newRow()
+		m.setRow(w, v)
+		i++
+		m.rows = slices.Insert(m.rows, i, w)
+	}
+	m.layoutRows()
+	m.changed()
+}
+
+func (m *multiWidget) removeRow(w fyne.CanvasObject) {
+	if len(m.rows) <= m.minRows {
+		return
+	}
+	for i, r := range m.rows {
+		if r == w {
+			m.rows = append(m.rows[:i], m.rows[i+1:]...)
+			break
+		}
+	}
+	m.layoutRows()
+	m.changed()
+}
+
+// 上移或下移一行
+func (m *multiWidget) moveRow(w fyne.CanvasObject, delta int) {
+	for i, r := range m.rows {
+		if r != w {
+			continue
+		}
+		j := i + delta
+		if j < 0 || j >= len(m.rows) {
+			return
+		}
+		m.rows[i], m.rows[j] = m.rows[j], m.rows[i]
+		m.layoutRows()
+		m.changed()
+		return
+	}
+}
+
+// 按当前顺序重新排列各行
+func (m *multiWidget) layoutRows() {
+	objects := make([]fyne.CanvasObject, 0, len(m.rows)+1)
+	for i, w := range m.rows {
+		row := w
+		upBtn := widget.NewButton("↑", func() { m.moveRow(row, -1) })
+		downBtn := widget.NewButton("↓", func() { m.moveRow(row, 1) })
+		removeBtn := widget.NewButton("-", func() { m.removeRow(row) })
+		if i == 0 || m.disabled {
+			upBtn.Disable()
+		}
+		if i == len(m.rows)-1 || m.disabled {
+			downBtn.Disable()
+		}
+		if len(m.rows) <= m.minRows || m.disabled {
+			removeBtn.Disable()
+		}
+		objects = append(objects, container.NewBorder(nil, nil, nil, container.NewHBox(upBtn, downBtn, removeBtn), w))
+	}
+	if m.disabled || m.maxCount > 0 && len(m.rows) >= m.maxCount {
+		m.addBtn.Disable()
+	} else {
+		m.addBtn.Enable()
+	}
+	m.vbox.Objects = append(objects, m.addBtn)
+	m.vbox.Refresh()
+}
+
+func (m *multiWidget) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(m.vbox)
+}
+
+func (m *multiWidget) MinSize() fyne.Size {
+	return m.vbox.MinSize()
+}
+
+func (m *multiWidget) Resize(size fyne.Size) {
+	m.BaseWidget.Resize(size)
+	m.vbox.Resize(size)
+}
+
+func (m *multiWidget) Enable() {
+	m.setDisabled(false)
+}
+
+func (m *multiWidget) Disable() {
+	m.setDisabled(true)
+}
+
+func (m *multiWidget) Disabled() bool {
+	return m.disabled
+}
+
+func (m *multiWidget) setDisabled(disabled bool) {
+	if m.disabled == disabled {
+		return
+	}
+	m.disabled = disabled
+	for _, w := range m.rows {
+		setObjectEnabled(w, !disabled)
+	}
+	m.layoutRows()
+}
+
+// 按行顺序返回非空值
+func (m *multiWidget) Values() []string {
+	var vals []string
+	for _, w := range m.rows {
+		if v := m.rowValue(w); v != "" {
+			vals = append(vals, v)
+		}
+	}
+	return vals
+}
+
+// 替换全部值，保留至少 minRows 行
+func (m *multiWidget) SetValues(vals []string) {
+	m.rows = nil
+	for _, v := range vals {
+		m.setRow(m.addRow(), v)
+	}
+	for len(m.rows) < m.minRows {
+		m.addRow()
+	}
+	if m.disabled {
+		for _, w := range m.rows {
+			setObjectEnabled(w, false)
+		}
+	}
+	m.layoutRows()
+	m.changed()
+}
+
+// 设置控件值变化时的回调
+func onWidgetChanged(w fyne.CanvasObject, fn func()) {
+	switch wt := w.(type) {
+	case *widget.Entry:
+		wt.OnChanged = func(string) { fn() }
+	case *widget.Select:
+		wt.OnChanged = func(string) { fn() }
+	case *widget.Check:
+		wt.OnChanged = func(bool) { fn() }
+	case *widget.RadioGroup:
+		wt.OnChanged = func(string) { fn() }
+	case *multiWidget:
+		wt.onChanged = fn
+	case *multiChoiceWidget:
+		wt.onChanged = fn
+	case *fyne.Container:
+		for _, obj := range wt.Objects {
+			switch o := obj.(type) {
+			case *widget.Entry:
+				o.OnChanged = func(string) { fn() }
+				return
+			case *widget.Select:
+				o.OnChanged = func(string) { fn() }
+				return
+			case *widget.RadioGroup:
+				o.OnChanged = func(string) { fn() }
+				return
+			}
+		}
+	}
+}
+
+// 启用或禁用控件，容器中的控件一并处理
+func setObjectEnabled(w fyne.CanvasObject, enabled bool) {
+	if dw, ok := w.(fyne.Disableable); ok {
+		if enabled {
+			dw.Enable()
+		} else {
+			dw.Disable()
+		}
+	}
+	if c, ok := w.(*fyne.Container); ok {
+		for _, obj := range c.Objects {
+			setObjectEnabled(obj, enabled)
+		}
+	}
+}
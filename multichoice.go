@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 选项超过该数量时显示为可搜索的列表
+const checkListThreshold = 8
+
+// multichoice 字段，选项少时为复选框组，多时为带搜索的列表
+type multiChoiceWidget struct {
+	widget.BaseWidget
+	choices  []string
+	selected map[string]bool
+	content  fyne.CanvasObject
+	disabled bool
+
+	// 复选框组
+	group *widget.CheckGroup
+	// 可搜索列表
+	search   *widget.Entry
+	list     *widget.List
+	filtered []string
+	summary  *widget.Label
+
+	onChanged func()
+}
+
+func newMultiChoiceWidget(choices []string, defaults []string) *multiChoiceWidget {
+	m := &multiChoiceWidget{choices: choices, selected: make(map[string]bool)}
+	if len(choices) <= checkListThreshold {
+		m.group = widget.NewCheckGroup(choices, func([]string) {
+			m.selected = make(map[string]bool)
+			for _, c := range m.group.Selected {
+				m.selected[c] = true
+			}
+			m.changed()
+		})
+		m.content = m.group
+	} else {
+		m.content = m.createList()
+	}
+	m.setSelected(defaults)
+	m.ExtendBaseWidget(m)
+	return m
+}
+
+func (m *multiChoiceWidget) createList() fyne.CanvasObject {
+	m.filtered = m.choices
+	m.search = widget.NewEntry()
+	m.search.SetPlaceHolder("Search...")
+	m.search.OnChanged = func(text string) {
+		m.filtered = nil
+		for _, c := range m.choices {
+			if strings.Contains(strings.ToLower(c), strings.ToLower(text)) {
+				m.filtered = append(m.filtered, c)
+			}
+		}
+		m.list.Refresh()
+	}
+	m.list = widget.NewList(
+		func() int { return len(m.filtered) },
+		func() fyne.CanvasObject { return widget.NewCheck("", nil) },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			check := obj.(*widget.Check)
+			choice := m.filtered[id]
+			// 先清除回调，避免复用行时误触发
+			check.OnChanged = nil
+			check.Text = choice
+			check.SetChecked(m.selected[choice])
+			check.OnChanged = func(on bool) {
+				m.selected[choice] = on
+				m.updateSummary()
+				m.changed()
+			}
+			if m.disabled {
+				check.Disable()
+			} else {
+				check.Enable()
+			}
+		},
+	)
+	m.summary = widget.NewLabel("")
+	// 列表本身没有最小高度
+	spacer := canvas.NewRectangle(color.Transparent)
+	spacer.SetMinSize(fyne.NewSize(0, 200))
+	return container.NewBorder(m.search, m.summary, nil, nil, container.NewStack(spacer, m.list))
+}
+
+func (m *multiChoiceWidget) changed() {
+	if m.onChanged != nil {
+		m.onChanged()
+	}
+}
+
+func (m *multiChoiceWidget) updateSummary() {
+	if m.summary != nil {
+		m.summary.SetText(fmt.Sprintf("%d selected", len(m.Values())))
+	}
+}
+
+// 设置选中项，忽略不在 choices 中的值
+func (m *multiChoiceWidget) setSelected(vals []string) {
+	m.selected = make(map[string]bool)
+	for _, v := range vals {
+		if slices.Contains(m.choices, v) {
+			m.selected[v] = true
+		}
+	}
+	if m.group != nil {
+		m.group.Selected = m.Values()
+		m.group.Refresh()
+	} else {
+		m.list.Refresh()
+		m.updateSummary()
+	}
+}
+
+// 按 choices 顺序返回选中项
+func (m *multiChoiceWidget) Values() []string {
+	var vals []string
+	for _, c := range m.choices {
+		if m.selected[c] {
+			vals = append(vals, c)
+		}
+	}
+	return vals
+}
+
+func (m *multiChoiceWidget) SetValues(vals []string) {
+	m.setSelected(vals)
+	m.changed()
+}
+
+func (m *multiChoiceWidget) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(m.content)
+}
+
+func (m *multiChoiceWidget) Enable() {
+	m.setDisabled(false)
+}
+
+func (m *multiChoiceWidget) Disable() {
+	m.setDisabled(true)
+}
+
+func (m *multiChoiceWidget) Disabled() bool {
+	return m.disabled
+}
+
+func (m *multiChoiceWidget) setDisabled(disabled bool) {
+	m.disabled = disabled
+	if m.group != nil {
+		setObjectEnabled(m.group, !disabled)
+		return
+	}
+	setObjectEnabled(m.search, !disabled)
+	m.list.Refresh()
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 带选择按钮的路径输入框
+func (u *AppUI) createPicker(item *Item, entry *widget.Entry) fyne.CanvasObject {
+	btnText := item.PickerText
+	if btnText == "" {
+		btnText = "..."
+	}
+	var row *fyne.Container
+	btn := widget.NewButton(btnText, func() { u.showPicker(item, row) })
+	row = container.NewBorder(nil, nil, nil, btn, entry)
+	return row
+}
+
+// 选择器行中的输入框
+func pickerEntry(row *fyne.Container) *widget.Entry {
+	for _, obj := range row.Objects {
+		if entry, ok := obj.(*widget.Entry); ok {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (u *AppUI) showPicker(item *Item, row *fyne.Container) {
+	entry := pickerEntry(row)
+	start := u.pickerStartDir(item, entry.Text)
+	switch item.Picker {
+	case "files":
+		u.showFilesDialog(item, start, func(paths []string) {
+			u.setPickedFiles(item, row, paths)
+		})
+		return
+	case "directory":
+		d := dialog.NewFolderOpen(func(f fyne.ListableURI, err error) {
+			if f != nil {
+				entry.SetText(f.Path())
+			}
+		}, u.window)
+		setDialogLocation(d, start)
+		d.Show()
+		return
+	case "save":
+		name := ""
+		if entry.Text != "" {
+			name = filepath.Base(entry.Text)
+		}
+		u.showSaveDialog(item, start, name, entry.SetText)
+		return
+	}
+	d := dialog.NewFileOpen(func(f fyne.URIReadCloser, err error) {
+		if f != nil {
+			entry.SetText(f.URI().Path())
+			f.Close()
+		}
+	}, u.window)
+	if exts := pickerExtensions(item.Filters); len(exts) > 0 {
+		d.SetFilter(storage.NewExtensionFileFilter(exts))
+	}
+	setDialogLocation(d, start)
+	d.Show()
+}
+
+// 设置对话框的起始目录，dir 为空时不修改
+func setDialogLocation(d *dialog.FileDialog, dir string) {
+	if dir == "" {
+		return
+	}
+	if loc, err := storage.ListerForURI(storage.NewFileURI(dir)); err == nil {
+		d.SetLocation(loc)
+	}
+}
+
+// 将 *.mp4、.mp4 或 mp4 形式的过滤器转为扩展名
+func pickerExtensions(filters []string) []string {
+	var exts []string
+	for _, f := range filters {
+		f = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(f), "*"))
+		if f == "" || f == "." {
+			continue
+		}
+		if !strings.HasPrefix(f, ".") {
+			f = "." + f
+		}
+		exts = append(exts, f)
+	}
+	return exts
+}
+
+// 文件名是否符合过滤器，没有过滤器时都符合
+func matchExtensions(path string, exts []string) bool {
+	if len(exts) == 0 {
+		return true
+	}
+	return slices.Contains(exts, strings.ToLower(filepath.Ext(path)))
+}
+
+// 对话框的起始目录，优先使用当前值所在的目录，其次为 start_dir
+func (u *AppUI) pickerStartDir(item *Item, current string) string {
+	var candidates []string
+	if current != "" {
+		candidates = append(candidates, filepath.Dir(current))
+	}
+	if item.StartDir != "" {
+		candidates = append(candidates, expandPath(item.StartDir, u.pathValue))
+	}
+	for _, dir := range candidates {
+		if abs, err := filepath.Abs(dir); err == nil {
+			if info, err := os.Stat(abs); err == nil && info.IsDir() {
+				return abs
+			}
+		}
+	}
+	return ""
+}
+
+// 根据 suggest 模板在引用的字段变化时填入建议值，用户修改过的值不会被覆盖
+func (u *AppUI) setupSuggestions() {
+	for i := range u.app.Items {
+		item := &u.app.Items[i]
+		if item.Suggest == "" || item.MultiValued() || u.widgets[item.Name] == nil {
+			continue
+		}
+		suggested := ""
+		update := func() {
+			w := u.widgets[item.Name]
+			current := u.getWidgetValue(item, w)
+			if current != "" && current != suggested {
+				return
+			}
+			empty := false
+			val := expandTemplate(item.Suggest, func(ref string) string {
+				v := u.templateValue(ref)
+				empty = empty || v == ""
+				return v
+			})
+			if empty {
+				val = ""
+			}
+			if val != current {
+				u.setWidgetValues(item, w, []string{val})
+			}
+			suggested = val
+		}
+		for _, ref := range templateNames(item.Suggest) {
+			name, _ := splitTemplateRef(ref)
+			u.watch(name, update)
+		}
+		update()
+	}
+}
+
+// 多文件选择对话框，每次在一个目录中勾选文件
+func (u *AppUI) showFilesDialog(item *Item, start string, done func([]string)) {
+	exts := pickerExtensions(item.Filters)
+	dir := start
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	pathLabel := widget.NewLabel("")
+	pathLabel.Truncation = fyne.TextTruncateEllipsis
+	files := newMultiChoiceWidget(nil, nil)
+	body := container.NewStack()
+	load := func(d string) {
+		names, err := listFiles(d, exts)
+		if err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		dir = d
+		pathLabel.SetText(d)
+		files = newMultiChoiceWidget(names, nil)
+		body.Objects = []fyne.CanvasObject{container.NewVScroll(files)}
+		body.Refresh()
+	}
+	load(dir)
+
+	upBtn := widget.NewButton("Up", func() { load(filepath.Dir(dir)) })
+	folderBtn := widget.NewButton("Folder...", func() {
+		dialog.ShowFolderOpen(func(f fyne.ListableURI, err error) {
+			if f != nil {
+				load(f.Path())
+			}
+		}, u.window)
+	})
+	allBtn := widget.NewButton("All", func() { files.SetValues(files.choices) })
+	top := container.NewBorder(nil, nil, nil, container.NewHBox(upBtn, folderBtn, allBtn), pathLabel)
+	content := container.NewBorder(top, nil, nil, nil, body)
+
+	d := dialog.NewCustomConfirm("Select Files", "Select", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		var paths []string
+		for _, name := range files.Values() {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		if len(paths) > 0 {
+			done(paths)
+		}
+	}, u.window)
+	d.Resize(fyne.NewSize(520, 420))
+	d.Show()
+}
+
+// 目录中符合过滤器的文件名，不含子目录和隐藏文件
+func listFiles(dir string, exts []string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || !matchExtensions(e.Name(), exts) {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	return names, nil
+}
+
+// 保存文件对话框，只选择路径，不会创建或打开文件
+func (u *AppUI) showSaveDialog(item *Item, start, name string, done func(string)) {
+	exts := pickerExtensions(item.Filters)
+	dir := start
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	pathLabel := widget.NewLabel("")
+	pathLabel.Truncation = fyne.TextTruncateEllipsis
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(name)
+	var names []string
+	files := widget.NewList(
+		func() int { return len(names) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) { obj.(*widget.Label).SetText(names[id]) },
+	)
+	// 点击已有文件时使用其文件名
+	files.OnSelected = func(id widget.ListItemID) { nameEntry.SetText(names[id]) }
+	load := func(d string) {
+		list, err := listFiles(d, exts)
+		if err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		dir, names = d, list
+		pathLabel.SetText(d)
+		files.UnselectAll()
+		files.Refresh()
+	}
+	load(dir)
+
+	upBtn := widget.NewButton("Up", func() { load(filepath.Dir(dir)) })
+	folderBtn := widget.NewButton("Folder...", func() {
+		dialog.ShowFolderOpen(func(f fyne.ListableURI, err error) {
+			if f != nil {
+				load(f.Path())
+			}
+		}, u.window)
+	})
+	top := container.NewBorder(nil, nil, nil, container.NewHBox(upBtn, folderBtn), pathLabel)
+	bottom := container.NewBorder(nil, nil, widget.NewLabel("Name"), nil, nameEntry)
+	content := container.NewBorder(top, bottom, nil, nil, files)
+
+	d := dialog.NewCustomConfirm("Save File", "Save", "Cancel", content, func(ok bool) {
+		if path := savePath(dir, nameEntry.Text); ok && path != "" {
+			done(path)
+		}
+	}, u.window)
+	d.Resize(fyne.NewSize(520, 420))
+	d.Show()
+}
+
+// 保存对话框中输入的文件名对应的路径，文件名为空时返回空字符串
+func savePath(dir, name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(dir, name)
+}
+
+// 将选择或拖入的文件填入字段。多值字段从该行开始依次填入，
+// 位置参数依次填入该字段及其后的位置参数，其他字段只取第一个文件
+func (u *AppUI) setPickedFiles(item *Item, row *fyne.Container, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	if mw, ok := u.widgets[item.Name].(*multiWidget); ok {
+		mw.fillFrom(row, paths)
+		return
+	}
+	if !item.Positional {
+		pickerEntry(row).SetText(paths[0])
+		return
+	}
+	found := false
+	for i := range u.app.Items {
+		next := &u.app.Items[i]
+		if next.Name == item.Name {
+			found = true
+		}
+		if !found || !next.Positional || next.Type != "string" || next.MultiValued() {
+			continue
+		}
+		if len(paths) == 0 {
+			return
+		}
+		u.setWidgetValues(next, u.widgets[next.Name], paths[:1])
+		paths = paths[1:]
+	}
+}
+
+// 处理拖放到窗口的文件。落在选择器行上时填入该行，否则填入第一个可用的文件选择器
+func (u *AppUI) handleDrop(pos fyne.Position, uris []fyne.URI) {
+	var paths []string
+	for _, uri := range uris {
+		if uri.Scheme() == "file" {
+			paths = append(paths, uri.Path())
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	var fallback *Item
+	var fallbackRow *fyne.Container
+	for i := range u.app.Items {
+		item := &u.app.Items[i]
+		if !pickers[item.Picker] || item.Type != "string" || !u.checkCondition(item) {
+			continue
+		}
+		for _, row := range u.pickerRows(item) {
+			if fallback == nil {
+				fallback, fallbackRow = item, row
+			}
+			if objectContains(row, pos) {
+				u.dropFiles(item, row, paths)
+				return
+			}
+		}
+	}
+	if fallback != nil {
+		u.dropFiles(fallback, fallbackRow, paths)
+	}
+}
+
+// 按选择器类型和过滤器筛选拖入的路径后填入
+func (u *AppUI) dropFiles(item *Item, row *fyne.Container, paths []string) {
+	exts := pickerExtensions(item.Filters)
+	var accepted []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil || info.IsDir() != (item.Picker == "directory") {
+			continue
+		}
+		if item.Picker != "directory" && !matchExtensions(p, exts) {
+			continue
+		}
+		accepted = append(accepted, p)
+	}
+	u.setPickedFiles(item, row, accepted)
+}
+
+// 字段的选择器行，多值字段每行一个
+func (u *AppUI) pickerRows(item *Item) []*fyne.Container {
+	var objs []fyne.CanvasObject
+	switch w := u.widgets[item.Name].(type) {
+	case *multiWidget:
+		objs = w.rows
+	default:
+		objs = []fyne.CanvasObject{w}
+	}
+	var rows []*fyne.Container
+	for _, obj := range objs {
+		if c, ok := obj.(*fyne.Container); ok && pickerEntry(c) != nil {
+			rows = append(rows, c)
+		}
+	}
+	return rows
+}
+
+// 窗口坐标是否落在控件内
+func objectContains(obj fyne.CanvasObject, pos fyne.Position) bool {
+	p := fyne.CurrentApp().Driver().AbsolutePositionForObject(obj)
+	size := obj.Size()
+	return pos.X >= p.X && pos.Y >= p.Y && pos.X < p.X+size.Width && pos.Y < p.Y+size.Height
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/test"
+)
+
+func TestPickerExtensions(t *testing.T) {
+	got := pickerExtensions([]string{"*.mp4", ".mkv", "webm", "*", " "})
+	want := []string{".mp4", ".mkv", ".webm"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pickerExtensions() = %v, want %v", got, want)
+	}
+}
+
+func TestPickerStartDir(t *testing.T) {
+	dir := t.TempDir()
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "src", Type: "string"},
+			{Name: "out", Type: "string", Picker: "save", StartDir: "${src}"},
+		},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.Build()
+	setEntryText(ui.widgets["src"], dir)
+
+	if got := ui.pickerStartDir(&app.Items[1], ""); got != dir {
+		t.Errorf("pickerStartDir() = %q, want start_dir %q", got, dir)
+	}
+	sub := filepath.Join(dir, "sub")
+	os.Mkdir(sub, 0o755)
+	if got := ui.pickerStartDir(&app.Items[1], filepath.Join(sub, "a.mp4")); got != sub {
+		t.Errorf("pickerStartDir() = %q, want directory of current value %q", got, sub)
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.mp4", "b.MKV", "c.txt", ".hidden.mp4"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0o644)
+	}
+	os.Mkdir(filepath.Join(dir, "sub.mp4"), 0o755)
+	got, err := listFiles(dir, []string{".mp4", ".mkv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.mp4", "b.MKV"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestSavePath(t *testing.T) {
+	tests := []struct {
+		dir, name, want string
+	}{
+		{"/out", "a.mp4", "/out/a.mp4"},
+		{"/out", " sub/a.mp4 ", "/out/sub/a.mp4"},
+		{"/out", "/tmp/x/../a.mp4", "/tmp/a.mp4"},
+		{"/out", "  ", ""},
+	}
+	for _, tt := range tests {
+		if got := savePath(tt.dir, tt.name); got != filepath.FromSlash(tt.want) {
+			t.Errorf("savePath(%q, %q) = %q, want %q", tt.dir, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "ffmpeg"},
+		Items: []Item{
+			{Name: "i", Type: "string", Picker: "file"},
+			{Name: "o", Type: "string", Picker: "save", Suggest: "${i:noext}.mp4"},
+		},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.Build()
+
+	in := ui.widgets["i"].(*fyne.Container).Objects[0]
+	out := ui.widgets["o"].(*fyne.Container)
+	setEntryText(in, "/v/a.mov")
+	if got := ui.getWidgetValue(&app.Items[1], out); got != "/v/a.mp4" {
+		t.Errorf("suggested = %q, want /v/a.mp4", got)
+	}
+	setEntryText(in, "/v/b.mov")
+	if got := ui.getWidgetValue(&app.Items[1], out); got != "/v/b.mp4" {
+		t.Errorf("suggested = %q, want /v/b.mp4", got)
+	}
+
+	// 用户修改后不再覆盖
+	ui.setWidgetValues(&app.Items[1], out, []string{"/tmp/mine.mp4"})
+	setEntryText(in, "/v/c.mov")
+	if got := ui.getWidgetValue(&app.Items[1], out); got != "/tmp/mine.mp4" {
+		t.Errorf("edited value = %q, want /tmp/mine.mp4", got)
+	}
+}
+
+func TestSetPickedFilesMulti(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "convert"},
+		Items:   []Item{{Name: "in", Type: "string", Picker: "files", Multi: true, Positional: true, MaxCount: 3}},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.Build()
+
+	mw := ui.widgets["in"].(*multiWidget)
+	mw.SetValues([]string{"first", "last"})
+	ui.setPickedFiles(&app.Items[0], mw.rows[0].(*fyne.Container), []string{"a", "b", "c"})
+	// 从第一行开始填入，受 max_count 限制
+	if got := mw.Values(); !reflect.DeepEqual(got, []string{"a", "b", "last"}) {
+		t.Errorf("Values() = %v, want [a b last]", got)
+	}
+}
+
+func TestSetPickedFilesPositionals(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmp"},
+		Items: []Item{
+			{Name: "v", Type: "bool", Short: true},
+			{Name: "a", Type: "string", Picker: "files", Positional: true},
+			{Name: "b", Type: "string", Picker: "file", Positional: true},
+		},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.Build()
+
+	ui.setPickedFiles(&app.Items[1], ui.widgets["a"].(*fyne.Container), []string{"x", "y", "z"})
+	if got := ui.BuildArgs(); !reflect.DeepEqual(got, []string{"x", "y"}) {
+		t.Errorf("BuildArgs() = %v, want [x y]", got)
+	}
+}
+
+func TestHandleDrop(t *testing.T) {
+	dir := t.TempDir()
+	png := filepath.Join(dir, "a.PNG")
+	txt := filepath.Join(dir, "b.txt")
+	os.WriteFile(png, nil, 0o644)
+	os.WriteFile(txt, nil, 0o644)
+
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "mode", Type: "string"},
+			{Name: "out", Type: "string", Picker: "directory", Condition: "mode"},
+			{Name: "img", Type: "string", Picker: "files", Multi: true, Filters: []string{"*.png"}},
+			{Name: "doc", Type: "string", Picker: "file"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	w.SetContent(ui.Build())
+	w.Resize(fyne.NewSize(400, 400))
+
+	uris := []fyne.URI{storage.NewFileURI(png), storage.NewFileURI(txt), storage.NewFileURI(dir)}
+	// 不在任何选择器上时填入第一个可用的选择器，跳过条件不满足的字段
+	ui.handleDrop(fyne.NewPos(-1, -1), uris)
+	if got := ui.itemValues(&app.Items[2]); !reflect.DeepEqual(got, []string{png}) {
+		t.Errorf("img = %v, want [%s]", got, png)
+	}
+
+	doc := ui.widgets["doc"]
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(doc).AddXY(1, 1)
+	ui.handleDrop(pos, uris)
+	if got := ui.itemValues(&app.Items[3]); !reflect.DeepEqual(got, []string{png}) {
+		t.Errorf("doc = %v, want first file %s", got, png)
+	}
+}
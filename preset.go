@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 配置文件中的只读预设
+type Preset struct {
+	Name   string         `toml:"name"`
+	Values map[string]any `toml:"values"`
+}
+
+// 预设值转为字段值列表，数组对应多值字段
+func presetItemValues(v any) []string {
+	switch val := v.(type) {
+	case nil:
+		return []string{}
+	case bool:
+		if val {
+			return []string{"true"}
+		}
+		return []string{}
+	case []any:
+		vals := []string{}
+		for _, e := range val {
+			vals = append(vals, fmt.Sprintf("%v", e))
+		}
+		return vals
+	default:
+		return []string{fmt.Sprintf("%v", val)}
+	}
+}
+
+func (u *AppUI) builtinPreset(name string) *Preset {
+	for i := range u.app.Presets {
+		if u.app.Presets[i].Name == name {
+			return &u.app.Presets[i]
+		}
+	}
+	return nil
+}
+
+func (u *AppUI) userPresets() map[string]map[string][]string {
+	var presets map[string]map[string][]string
+	if u.store != nil {
+		u.store.update(u.stateKey, func(st *appState) bool {
+			presets = st.Presets
+			return false
+		})
+	}
+	return presets
+}
+
+// 预设名称列表，配置中的预设在前
+func (u *AppUI) presetNames() []string {
+	var names []string
+	for _, p := range u.app.Presets {
+		names = append(names, p.Name)
+	}
+	var user []string
+	for name := range u.userPresets() {
+		if u.builtinPreset(name) == nil {
+			user = append(user, name)
+		}
+	}
+	sort.Strings(user)
+	return append(names, user...)
+}
+
+// 加载预设，只修改预设中包含的字段
+func (u *AppUI) loadPreset(name string) error {
+	values := make(map[string][]string)
+	if p := u.builtinPreset(name); p != nil {
+		for k, v := range p.Values {
+			values[k] = presetItemValues(v)
+			// tristate 的 false 为 Off，与默认值相同
+			if item := u.findItem(k); item != nil && item.Type == "bool" && item.Tristate && v == false {
+				values[k] = []string{"false"}
+			}
+		}
+	} else if saved, ok := u.userPresets()[name]; ok {
+		values = saved
+	} else {
+		return fmt.Errorf("preset %q not found", name)
+	}
+	for i := range u.app.Items {
+		item := &u.app.Items[i]
+		if vals, ok := values[item.Name]; ok && !item.IsLabel() {
+			u.setWidgetValues(item, u.widgets[item.Name], vals)
+		}
+	}
+	return nil
+}
+
+func (u *AppUI) savePreset(name string) error {
+	if name == "" {
+		return errors.New("preset name is required")
+	}
+	if u.builtinPreset(name) != nil {
+		return fmt.Errorf("preset %q is read-only", name)
+	}
+	if u.store == nil {
+		return errors.New("presets cannot be saved")
+	}
+	values := u.rememberedValues()
+	return u.store.update(u.stateKey, func(st *appState) bool {
+		if st.Presets == nil {
+			st.Presets = make(map[string]map[string][]string)
+		}
+		st.Presets[name] = values
+		return true
+	})
+}
+
+func (u *AppUI) renamePreset(oldName, newName string) error {
+	if newName == "" {
+		return errors.New("preset name is required")
+	}
+	if u.builtinPreset(oldName) != nil || u.builtinPreset(newName) != nil {
+		return errors.New("built-in presets are read-only")
+	}
+	if u.store == nil {
+		return fmt.Errorf("preset %q not found", oldName)
+	}
+	var err error
+	u.store.update(u.stateKey, func(st *appState) bool {
+		values, ok := st.Presets[oldName]
+		if !ok {
+			err = fmt.Errorf("preset %q not found", oldName)
+			return false
+		}
+		if _, exists := st.Presets[newName]; exists {
+			err = fmt.Errorf("preset %q already exists", newName)
+			return false
+		}
+		delete(st.Presets, oldName)
+		st.Presets[newName] = values
+		return true
+	})
+	return err
+}
+
+func (u *AppUI) deletePreset(name string) error {
+	if u.builtinPreset(name) != nil {
+		return fmt.Errorf("preset %q is read-only", name)
+	}
+	if u.store == nil {
+		return fmt.Errorf("preset %q not found", name)
+	}
+	return u.store.update(u.stateKey, func(st *appState) bool {
+		delete(st.Presets, name)
+		return true
+	})
+}
+
+// 预设工具栏：选择即加载，支持保存、重命名、删除
+func (u *AppUI) buildPresetBar() fyne.CanvasObject {
+	sel := widget.NewSelect(u.presetNames(), nil)
+	sel.PlaceHolder = "Presets"
+
+	var renameBtn, deleteBtn *widget.Button
+	refresh := func(selected string) {
+		sel.Options = u.presetNames()
+		if selected == "" || !slices.Contains(sel.Options, selected) {
+			sel.ClearSelected()
+		} else {
+			sel.SetSelected(selected)
+		}
+		sel.Refresh()
+	}
+	updateButtons := func(name string) {
+		editable := name != "" && u.builtinPreset(name) == nil && u.store != nil
+		if editable {
+			renameBtn.Enable()
+			deleteBtn.Enable()
+		} else {
+			renameBtn.Disable()
+			deleteBtn.Disable()
+		}
+	}
+
+	askName := func(title, initial string, fn func(string) error) {
+		entry := widget.NewEntry()
+		entry.SetText(initial)
+		dialog.ShowForm(title, "OK", "Cancel", []*widget.FormItem{widget.NewFormItem("Name", entry)}, func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := fn(entry.Text); err != nil {
+				dialog.ShowError(err, u.window)
+				return
+			}
+			refresh(entry.Text)
+		}, u.window)
+	}
+
+	saveBtn := widget.NewButton("Save", func() {
+		// 同名的用户预设会被覆盖
+		initial := sel.Selected
+		if u.builtinPreset(initial) != nil {
+			initial = ""
+		}
+		askName("Save Preset", initial, u.savePreset)
+	})
+	renameBtn = widget.NewButton("Rename", func() {
+		old := sel.Selected
+		askName("Rename Preset", old, func(name string) error { return u.renamePreset(old, name) })
+	})
+	deleteBtn = widget.NewButton("Delete", func() {
+		name := sel.Selected
+		dialog.ShowConfirm("Delete Preset", fmt.Sprintf("Delete preset %q?", name), func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := u.deletePreset(name); err != nil {
+				dialog.ShowError(err, u.window)
+				return
+			}
+			refresh("")
+		}, u.window)
+	})
+
+	sel.OnChanged = func(name string) {
+		updateButtons(name)
+		if name == "" {
+			return
+		}
+		if err := u.loadPreset(name); err != nil {
+			dialog.ShowError(err, u.window)
+		}
+	}
+	updateButtons("")
+	if u.store == nil {
+		saveBtn.Disable()
+	}
+	return container.NewBorder(nil, nil, nil, container.NewHBox(saveBtn, renameBtn, deleteBtn), sel)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func presetTestApp() *App {
+	return &App{
+		Command: Command{Path: "ffmpeg", Name: "Transcode"},
+		Items: []Item{
+			{Name: "i", Type: "string", Short: true, Separator: " "},
+			{Name: "c:v", Type: "choice", Short: true, Separator: " ", Choices: []string{"libx264", "libx265"}},
+			{Name: "crf", Type: "number", Default: int64(23)},
+			{Name: "fast", Type: "bool"},
+			{Name: "tag", Type: "string", Multi: true},
+		},
+		Presets: []Preset{
+			{Name: "web", Values: map[string]any{"c:v": "libx264", "crf": int64(28), "fast": true, "tag": []any{"a", "b"}}},
+		},
+	}
+}
+
+func TestLoadBuiltinPreset(t *testing.T) {
+	app := presetTestApp()
+	ui := newStoredUI(t, app, t.TempDir())
+	setEntryText(ui.widgets["i"], "in.mov")
+
+	if err := ui.loadPreset("web"); err != nil {
+		t.Fatal(err)
+	}
+	// 预设未包含的字段保持不变
+	want := []string{"-i", "in.mov", "-c:v", "libx264", "--crf=28", "--fast", "--tag=a", "--tag=b"}
+	if got := ui.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadBuiltinPresetTristate(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd", Name: "Tool"},
+		Items: []Item{
+			{Name: "c", Type: "bool", Tristate: true, Default: true, Off: []string{"--no-c"}},
+			{Name: "d", Type: "bool", Default: true},
+		},
+		Presets: []Preset{{Name: "off", Values: map[string]any{"c": false, "d": false}}},
+	}
+	ui := newStoredUI(t, app, t.TempDir())
+	if err := ui.loadPreset("off"); err != nil {
+		t.Fatal(err)
+	}
+	// tristate 的 false 加载为 Off，普通 bool 加载为未选中
+	want := []string{"--no-c"}
+	if got := ui.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestUserPresetLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	app := presetTestApp()
+	ui := newStoredUI(t, app, dir)
+
+	setEntryText(ui.widgets["crf"], "18")
+	setSelected(ui.widgets["c:v"], "libx265")
+	if err := ui.savePreset("archive"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ui.presetNames(); !reflect.DeepEqual(got, []string{"web", "archive"}) {
+		t.Errorf("presetNames() = %v, want [web archive]", got)
+	}
+
+	// 新会话中可以加载
+	other := newStoredUI(t, app, dir)
+	if err := other.loadPreset("archive"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"-c:v", "libx265", "--crf=18"}
+	if got := other.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+
+	if err := other.renamePreset("archive", "master"); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.loadPreset("archive"); err == nil {
+		t.Error("loadPreset(archive) after rename should fail")
+	}
+	if err := other.deletePreset("master"); err != nil {
+		t.Fatal(err)
+	}
+	if got := other.presetNames(); !reflect.DeepEqual(got, []string{"web"}) {
+		t.Errorf("presetNames() = %v, want [web]", got)
+	}
+}
+
+func TestBuiltinPresetReadOnly(t *testing.T) {
+	ui := newStoredUI(t, presetTestApp(), t.TempDir())
+	if err := ui.savePreset("web"); err == nil {
+		t.Error("savePreset(web) should fail for built-in preset")
+	}
+	if err := ui.deletePreset("web"); err == nil {
+		t.Error("deletePreset(web) should fail for built-in preset")
+	}
+	if err := ui.renamePreset("web", "x"); err == nil {
+		t.Error("renamePreset(web) should fail for built-in preset")
+	}
+}
+
+func TestLoadConfigPresets(t *testing.T) {
+	path := writeTempFile(t, `
+[[apps]]
+[apps.command]
+path = "ffmpeg"
+
+[[apps.items]]
+name = "c:v"
+type = "choice"
+choices = ["libx264", "copy"]
+
+[[apps.presets]]
+name = "web"
+[apps.presets.values]
+"c:v" = "libx264"
+
+[[apps.presets]]
+name = "bad"
+[apps.presets.values]
+"c:v" = "vp9"
+missing = 1
+`)
+	cfg, diags := loadConfig(path)
+	if len(cfg.Apps[0].Presets) != 2 || cfg.Apps[0].Presets[0].Values["c:v"] != "libx264" {
+		t.Errorf("Presets = %+v", cfg.Apps[0].Presets)
+	}
+	if d := findDiagnostic(diags, "apps[0].presets[1].values.c:v"); d == nil || d.Line != 19 {
+		t.Errorf("choice diagnostic = %v, want line 19", d)
+	}
+	if d := findDiagnostic(diags, "apps[0].presets[1].values.missing"); d == nil || d.Severity != SeverityError {
+		t.Errorf("unknown field diagnostic = %v, want error", d)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// 取消时默认发送的信号和等待时间
+const (
+	defaultStopSignal  = "SIGTERM"
+	defaultStopTimeout = 5 * time.Second
+)
+
+// 可用于 stop_signal 的信号
+var stopSignals = map[string]bool{
+	"SIGINT":  true,
+	"SIGTERM": true,
+	"SIGHUP":  true,
+	"SIGQUIT": true,
+	"SIGKILL": true,
+	"SIGUSR1": true,
+	"SIGUSR2": true,
+}
+
+// 信号名统一为大写并带 SIG 前缀，如 int 为 SIGINT
+func normalizeSignal(name string) string {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name != "" && !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	return name
+}
+
+// 取消时发送的信号和升级为 SIGKILL 前的等待时间，配置无效时使用默认值
+func (c *Command) stopSettings() (string, time.Duration) {
+	sig := normalizeSignal(c.StopSignal)
+	if !stopSignals[sig] {
+		sig = defaultStopSignal
+	}
+	timeout, err := time.ParseDuration(c.StopTimeout)
+	if err != nil || timeout < 0 {
+		timeout = defaultStopTimeout
+	}
+	return sig, timeout
+}
+
+// 进程结束方式的说明
+func describeExit(err error) string {
+	var ee *exec.ExitError
+	switch {
+	case err == nil:
+		return "exited with code 0"
+	case errors.As(err, &ee):
+		if ee.Exited() {
+			return fmt.Sprintf("exited with code %d", ee.ExitCode())
+		}
+		return "ended by " + ee.ProcessState.String()
+	}
+	return err.Error()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// 按配置文件保存的表单状态
+type configState struct {
+	Apps map[string]*appState `json:"apps"`
+}
+
+type appState struct {
+	// 上次运行时各字段的值
+	Last map[string][]string `json:"last,omitempty"`
+	// 用户保存的预设
+	Presets map[string]map[string][]string `json:"presets,omitempty"`
+	// 工作目录选择器上次的值
+	Dir *string `json:"dir,omitempty"`
+}
+
+type stateStore struct {
+	mu   sync.Mutex
+	path string
+	data configState
+}
+
+// 状态文件目录
+func stateDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "cliface")
+}
+
+// 打开配置文件对应的状态存储，dir 或 configPath 为空时返回 nil
+func openStateStore(dir, configPath string) *stateStore {
+	if dir == "" || configPath == "" {
+		return nil
+	}
+	s := &stateStore{path: filepath.Join(dir, stateFileBase(configPath)+".json")}
+	if data, err := os.ReadFile(s.path); err == nil {
+		json.Unmarshal(data, &s.data)
+	}
+	if s.data.Apps == nil {
+		s.data.Apps = make(map[string]*appState)
+	}
+	return s
+}
+
+// 状态文件名，由配置文件名和路径哈希组成
+func stateFileBase(configPath string) string {
+	sum := sha1.Sum([]byte(configPath))
+	name := strings.TrimSuffix(filepath.Base(configPath), filepath.Ext(configPath))
+	return name + "-" + hex.EncodeToString(sum[:4])
+}
+
+// 读取或修改 app 的状态，fn 返回 true 时写回文件
+func (s *stateStore) update(key string, fn func(st *appState) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.data.Apps[key]
+	if st == nil {
+		st = &appState{}
+		s.data.Apps[key] = st
+	}
+	if !fn(st) {
+		return nil
+	}
+	return s.save()
+}
+
+func (s *stateStore) save() error {
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// 当前表单中需要记住的字段值
+func (u *AppUI) rememberedValues() map[string][]string {
+	values := make(map[string][]string)
+	for i := range u.app.Items {
+		item := &u.app.Items[i]
+		if item.IsLabel() || !item.Remembered() {
+			continue
+		}
+		values[item.Name] = u.itemValues(item)
+	}
+	return values
+}
+
+// 保存上次运行的值
+func (u *AppUI) saveLastValues() error {
+	if u.store == nil {
+		return nil
+	}
+	values := u.rememberedValues()
+	return u.store.update(u.stateKey, func(st *appState) bool {
+		st.Last = values
+		st.Dir = nil
+		if u.dirEntry != nil {
+			dir := u.dirEntry.Text
+			st.Dir = &dir
+		}
+		return true
+	})
+}
+
+// 恢复上次运行的值，未保存过的字段保持默认值
+func (u *AppUI) restoreLastValues() {
+	if u.store == nil {
+		return
+	}
+	var last map[string][]string
+	var dir *string
+	u.store.update(u.stateKey, func(st *appState) bool {
+		last = st.Last
+		dir = st.Dir
+		return false
+	})
+	if dir != nil && u.dirEntry != nil {
+		u.dirEntry.SetText(*dir)
+	}
+	for i := range u.app.Items {
+		item := &u.app.Items[i]
+		if item.IsLabel() || !item.Remembered() {
+			continue
+		}
+		if vals, ok := last[item.Name]; ok {
+			u.setWidgetValues(item, u.widgets[item.Name], vals)
+		}
+	}
+}
+
+// 恢复默认值并清除保存的值
+func (u *AppUI) resetToDefaults() {
+	for i := range u.app.Items {
+		item := &u.app.Items[i]
+		if item.IsLabel() {
+			continue
+		}
+		u.setWidgetValues(item, u.widgets[item.Name], item.DefaultValues())
+	}
+	if u.dirEntry != nil {
+		u.dirEntry.SetText(u.app.Command.Dir)
+	}
+	if u.store != nil {
+		u.store.update(u.stateKey, func(st *appState) bool {
+			st.Last = nil
+			st.Dir = nil
+			return true
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+func newStoredUI(t *testing.T, app *App, dir string) *AppUI {
+	t.Helper()
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.setStore(openStateStore(dir, "/tmp/tools.toml"), 0)
+	ui.Build()
+	return ui
+}
+
+func TestOpenStateStoreDisabled(t *testing.T) {
+	if openStateStore("", "/tmp/a.toml") != nil {
+		t.Error("empty dir should disable store")
+	}
+	if openStateStore(t.TempDir(), "") != nil {
+		t.Error("empty config path should disable store")
+	}
+}
+
+func TestRememberLastValues(t *testing.T) {
+	dir := t.TempDir()
+	no := false
+	app := &App{
+		Command: Command{Path: "cmd", Name: "Tool"},
+		Items: []Item{
+			{Name: "input", Type: "string", Picker: "file"},
+			{Name: "crf", Type: "number", Default: int64(23)},
+			{Name: "fast", Type: "bool", Default: true},
+			{Name: "fmt", Type: "choice", Choices: []string{"mp4", "mkv"}},
+			{Name: "tag", Type: "string", Multi: true},
+			{Name: "token", Type: "string", Remember: &no},
+			{Name: "pass", Type: "password"},
+		},
+	}
+
+	ui := newStoredUI(t, app, dir)
+	setContainerEntryText(ui.widgets["input"], "/videos/a.mov")
+	setEntryText(ui.widgets["crf"], "18")
+	ui.widgets["fast"].(*widget.Check).SetChecked(false)
+	setSelected(ui.widgets["fmt"], "mkv")
+	ui.widgets["tag"].(*multiWidget).SetValues([]string{"x", "y"})
+	setEntryText(ui.widgets["token"], "secret")
+	setEntryText(ui.widgets["pass"], "hunter2")
+	if err := ui.saveLastValues(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 新会话恢复上次的值
+	restored := newStoredUI(t, app, dir)
+	want := []string{"--input=/videos/a.mov", "--crf=18", "--fmt=mkv", "--tag=x", "--tag=y"}
+	if got := restored.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestRememberUnsavedItemKeepsDefault(t *testing.T) {
+	dir := t.TempDir()
+	app := &App{
+		Command: Command{Path: "cmd", Name: "Tool"},
+		Items:   []Item{{Name: "a", Type: "string"}},
+	}
+	ui := newStoredUI(t, app, dir)
+	setEntryText(ui.widgets["a"], "1")
+	ui.saveLastValues()
+
+	// 配置新增字段后，新字段使用默认值
+	app.Items = append(app.Items, Item{Name: "b", Type: "string", Default: "def"})
+	restored := newStoredUI(t, app, dir)
+	want := []string{"--a=1", "--b=def"}
+	if got := restored.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestResetToDefaults(t *testing.T) {
+	dir := t.TempDir()
+	app := &App{
+		Command: Command{Path: "cmd", Name: "Tool"},
+		Items: []Item{
+			{Name: "str", Type: "string", Default: "default_val"},
+			{Name: "flag", Type: "bool", Default: true},
+			{Name: "fmt", Type: "choice", Choices: []string{"a", "b"}},
+			{Name: "tag", Type: "string", Multi: true, Default: []any{"x", "y"}},
+		},
+	}
+	ui := newStoredUI(t, app, dir)
+	want := []string{"--str=default_val", "--flag", "--tag=x", "--tag=y"}
+	if got := ui.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("initial BuildArgs() = %v, want %v", got, want)
+	}
+	setEntryText(ui.widgets["str"], "changed")
+	ui.widgets["flag"].(*widget.Check).SetChecked(false)
+	setSelected(ui.widgets["fmt"], "b")
+	ui.widgets["tag"].(*multiWidget).SetValues([]string{"z"})
+	ui.saveLastValues()
+
+	ui.resetToDefaults()
+	if got := ui.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+
+	// 重置后保存的值也被清除
+	restored := newStoredUI(t, app, dir)
+	if got := restored.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("restored BuildArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestItemDefaultValues(t *testing.T) {
+	tests := []struct {
+		item Item
+		want []string
+	}{
+		{Item{Type: "string"}, nil},
+		{Item{Type: "string", Default: "x"}, []string{"x"}},
+		{Item{Type: "number", Default: int64(5)}, []string{"5"}},
+		{Item{Type: "bool", Default: true}, []string{"true"}},
+		{Item{Type: "bool", Default: false}, nil},
+		{Item{Type: "bool", Default: false, Tristate: true}, []string{"false"}},
+		{Item{Type: "bool", Tristate: true}, nil},
+		{Item{Type: "string", Multi: true}, []string{}},
+		{Item{Type: "string", Multi: true, Default: "x"}, []string{"x"}},
+		{Item{Type: "number", Multi: true, Default: []any{int64(1), int64(2)}}, []string{"1", "2"}},
+		{Item{Type: "multichoice", Default: "a"}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		if got := tt.item.DefaultValues(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v.DefaultValues() = %v, want %v", tt.item, got, tt.want)
+		}
+	}
+}
+
+func setContainerEntryText(w interface{}, text string) {
+	if c, ok := w.(*fyne.Container); ok {
+		for _, obj := range c.Objects {
+			if entry, ok := obj.(*widget.Entry); ok {
+				entry.SetText(text)
+				return
+			}
+		}
+	}
+}
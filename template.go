@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// 展开模板中的 ${name} 占位符，$$ 表示 $，未闭合的 ${ 原样保留
+func expandTemplate(s string, lookup func(name string) string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				b.WriteByte(s[i])
+				continue
+			}
+			b.WriteString(lookup(s[i+2 : i+2+end]))
+			i += end + 2
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// 展开路径开头的 ~ 和其中的 ${NAME} 或 $NAME，语法与 expandTemplate 相同，
+// lookup 找不到时使用同名环境变量
+func expandPath(s string, lookup func(name string) (string, bool)) string {
+	s = expandTemplate(braceRefs(s), func(name string) string {
+		if v, ok := lookup(name); ok {
+			return v
+		}
+		return os.Getenv(name)
+	})
+	if s == "~" || strings.HasPrefix(s, "~/") || strings.HasPrefix(s, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			s = filepath.Join(home, s[1:])
+		}
+	}
+	return s
+}
+
+// 将 $NAME 改写为 ${NAME}，$$ 保持不变
+func braceRefs(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		if s[i+1] == '$' {
+			b.WriteString("$$")
+			i++
+			continue
+		}
+		end := i + 1
+		for end < len(s) && (s[end] == '_' || isASCIILetter(s[end]) || end > i+1 && s[end] >= '0' && s[end] <= '9') {
+			end++
+		}
+		if end == i+1 {
+			b.WriteByte('$')
+			continue
+		}
+		b.WriteString("${" + s[i+1:end] + "}")
+		i = end - 1
+	}
+	return b.String()
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// 模板中引用的名称，去重并保持顺序
+func templateNames(s string) []string {
+	var names []string
+	expandTemplate(s, func(name string) string {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+		return ""
+	})
+	return names
+}
+
+// 引用中的路径修饰符，如 ${input:stem}
+var pathModifiers = map[string]func(string) string{
+	"dir":  filepath.Dir,
+	"base": filepath.Base,
+	"ext":  filepath.Ext,
+	"stem": func(p string) string {
+		base := filepath.Base(p)
+		return strings.TrimSuffix(base, filepath.Ext(base))
+	},
+	"noext": func(p string) string { return strings.TrimSuffix(p, filepath.Ext(p)) },
+}
+
+// 拆分引用中的字段名和路径修饰符，字段名本身可以含有 :
+func splitTemplateRef(ref string) (name, mod string) {
+	if i := strings.LastIndexByte(ref, ':'); i >= 0 && pathModifiers[ref[i+1:]] != nil {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// 按修饰符取路径的一部分，空值保持为空
+func applyPathModifier(val, mod string) string {
+	if val == "" || mod == "" {
+		return val
+	}
+	return pathModifiers[mod](val)
+}
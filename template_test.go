@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	values := map[string]string{"name": "world", "c:v": "libx264"}
+	lookup := func(name string) string { return values[name] }
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"hello ${name}", "hello world"},
+		{"${c:v}-${missing}!", "libx264-!"},
+		{"cost $$5 and $HOME", "cost $5 and $HOME"},
+		{"open ${name", "open ${name"},
+		{"end$", "end$"},
+	}
+	for _, tt := range tests {
+		if got := expandTemplate(tt.input, lookup); got != tt.want {
+			t.Errorf("expandTemplate(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestTemplateNames(t *testing.T) {
+	got := templateNames("${a} ${b} $${c} ${a}")
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("templateNames() = %v, want %v", got, want)
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	t.Setenv("CLIFACE_TEST_DIR", "/srv")
+	lookup := func(name string) (string, bool) {
+		if name == "repo" {
+			return "/work/repo", true
+		}
+		return "", false
+	}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"/abs/path", "/abs/path"},
+		{"~", home},
+		{"~/src", filepath.Join(home, "src")},
+		{"${CLIFACE_TEST_DIR}/app", "/srv/app"},
+		{"$CLIFACE_TEST_DIR/app", "/srv/app"},
+		{"$repo/src", "/work/repo/src"},
+		// $$ 表示 $
+		{"/data/$$cache/${repo}", "/data/$cache//work/repo"},
+		{"/data/$$repo", "/data/$repo"},
+		{"/cost/$5", "/cost/$5"},
+		{"${repo}", "/work/repo"},
+		{"a~b", "a~b"},
+	}
+	for _, tt := range tests {
+		if got := expandPath(tt.input, lookup); got != tt.want {
+			t.Errorf("expandPath(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPathModifiers(t *testing.T) {
+	tests := []struct {
+		ref      string
+		name     string
+		mod      string
+		modified string
+	}{
+		{"in:dir", "in", "dir", "/videos"},
+		{"in:base", "in", "base", "clip.final.mov"},
+		{"in:ext", "in", "ext", ".mov"},
+		{"in:stem", "in", "stem", "clip.final"},
+		{"in:noext", "in", "noext", "/videos/clip.final"},
+		{"c:v", "c:v", "", "/videos/clip.final.mov"},
+		{"c:v:stem", "c:v", "stem", "clip.final"},
+	}
+	for _, tt := range tests {
+		name, mod := splitTemplateRef(tt.ref)
+		if name != tt.name || mod != tt.mod {
+			t.Errorf("splitTemplateRef(%q) = %q, %q, want %q, %q", tt.ref, name, mod, tt.name, tt.mod)
+		}
+		if got := applyPathModifier("/videos/clip.final.mov", mod); got != tt.modified {
+			t.Errorf("applyPathModifier(%q) = %q, want %q", mod, got, tt.modified)
+		}
+	}
+	if got := applyPathModifier("", "dir"); got != "" {
+		t.Errorf("applyPathModifier(\"\", dir) = %q, want empty", got)
+	}
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"regexp"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -18,30 +21,80 @@ type AppUI struct {
 	app     *App
 	widgets map[string]fyne.CanvasObject
 	window  fyne.Window
+	// 状态存储，为 nil 时不保存
+	store    *stateStore
+	stateKey string
+	// 执行历史，为 nil 时不记录
+	history *historyStore
+	// 同一配置下的所有 app
+	peers []*AppUI
+	// 表单容器及每个字段所占的行（字段行和说明行）
+	form *fyne.Container
+	rows map[string][]fyne.CanvasObject
+	// 工作目录选择器，未启用时为 nil
+	dirEntry *widget.Entry
+	// 字段值变化时的回调
+	watchers map[string][]func()
+	// 准备批量执行时当前的输入文件
+	batchInput string
+	// 执行中和排队的任务，同一配置下的 app 共用
+	jobs *jobManager
 }
 
 func BuildUI(cfg *Config, w fyne.Window) fyne.CanvasObject {
-	if len(cfg.Apps) == 1 {
-		ui := NewAppUI(&cfg.Apps[0], w)
-		return ui.Build()
-	}
-	tabs := container.NewAppTabs()
+	store := openStateStore(stateDir(), cfg.Path)
+	history := openHistory(stateDir(), cfg.Path)
+	jobs := newJobManager(cfg.MaxJobs)
+	interceptClose(w, jobs)
+	var uis []*AppUI
 	for i := range cfg.Apps {
 		ui := NewAppUI(&cfg.Apps[i], w)
+		ui.setStore(store, i)
+		ui.history = history
+		ui.jobs = jobs
+		uis = append(uis, ui)
+	}
+	for _, ui := range uis {
+		ui.peers = uis
+	}
+	if len(uis) == 1 {
+		w.SetOnDropped(uis[0].handleDrop)
+		return uis[0].Build()
+	}
+	tabs := container.NewAppTabs()
+	for i, ui := range uis {
 		tabs.Append(container.NewTabItem(cfg.Apps[i].Command.Name, ui.Build()))
 	}
+	// 拖入的文件交给当前标签页
+	w.SetOnDropped(func(pos fyne.Position, uris []fyne.URI) {
+		uis[tabs.SelectedIndex()].handleDrop(pos, uris)
+	})
 	return tabs
 }
 
 func NewAppUI(app *App, w fyne.Window) *AppUI {
 	return &AppUI{
-		app:     app,
-		widgets: make(map[string]fyne.CanvasObject),
-		window:  w,
+		app:      app,
+		widgets:  make(map[string]fyne.CanvasObject),
+		rows:     make(map[string][]fyne.CanvasObject),
+		watchers: make(map[string][]func()),
+		window:   w,
+		jobs:     newJobManager(0),
+	}
+}
+
+// 按 app 名称保存状态，没有名称时使用序号
+func (u *AppUI) setStore(store *stateStore, index int) {
+	u.store = store
+	u.stateKey = u.app.Command.Name
+	if u.stateKey == "" {
+		u.stateKey = fmt.Sprintf("#%d", index)
 	}
 }
 
 func (u *AppUI) Build() fyne.CanvasObject {
+	u.setupBatchItem()
+
 	// 计算最大label宽度
 	var maxWidth float32
 	for _, item := range u.app.Items {
@@ -57,8 +110,17 @@ func (u *AppUI) Build() fyne.CanvasObject {
 			maxWidth = w
 		}
 	}
+	if u.app.Command.DirPicker {
+		if w := widget.NewLabel(dirPickerLabel).MinSize().Width; w > maxWidth {
+			maxWidth = w
+		}
+	}
 
 	form := container.New(&noSpaceVBox{})
+	u.form = form
+	if u.store != nil || len(u.app.Presets) > 0 {
+		form.Add(container.NewPadded(u.buildPresetBar()))
+	}
 	for i := range u.app.Items {
 		item := &u.app.Items[i]
 		if item.IsLabel() {
@@ -76,15 +138,23 @@ func (u *AppUI) Build() fyne.CanvasObject {
 		lbl.Alignment = fyne.TextAlignTrailing
 		labelBox := container.NewHBox(layout.NewSpacer(), lbl)
 		labelCol := container.NewGridWrap(fyne.NewSize(maxWidth+10, 0), labelBox)
-		row := container.NewBorder(nil, nil, labelCol, nil, w)
-		form.Add(container.NewPadded(row))
+		row := container.NewPadded(container.NewBorder(nil, nil, labelCol, nil, w))
+		form.Add(row)
+		u.rows[item.Name] = []fyne.CanvasObject{row}
 		if item.Description != "" {
 			hint := widget.NewLabel(item.Description)
 			hint.Wrapping = fyne.TextWrapWord
 			hintRow := container.NewBorder(nil, nil, container.NewGridWrap(fyne.NewSize(maxWidth+10, 0)), nil, hint)
 			form.Add(hintRow)
+			u.rows[item.Name] = append(u.rows[item.Name], hintRow)
 		}
 	}
+	if u.app.Command.DirPicker {
+		lbl := widget.NewLabel(dirPickerLabel)
+		lbl.Alignment = fyne.TextAlignTrailing
+		labelCol := container.NewGridWrap(fyne.NewSize(maxWidth+10, 0), container.NewHBox(layout.NewSpacer(), lbl))
+		form.Add(container.NewPadded(container.NewBorder(nil, nil, labelCol, nil, u.createDirPicker())))
+	}
 
 	runText := u.app.Command.RunText
 	if runText == "" {
@@ -92,8 +162,10 @@ func (u *AppUI) Build() fyne.CanvasObject {
 	}
 	runBtn := widget.NewButton(runText, func() { u.Execute() })
 	runBtn.Importance = parseImportance(u.app.Command.RunColor)
+	resetBtn := widget.NewButton("Reset to defaults", func() { u.resetToDefaults() })
+	resetBtn.Importance = widget.LowImportance
 
-	var buttons fyne.CanvasObject
+	extra := container.NewHBox(widget.NewButton("Paste Command", func() { u.pasteCommand() }))
 	if u.app.Command.Debug {
 		debugText := u.app.Command.DebugText
 		if debugText == "" {
@@ -101,21 +173,27 @@ func (u *AppUI) Build() fyne.CanvasObject {
 		}
 		debugBtn := widget.NewButton(debugText, func() { u.showCommand() })
 		debugBtn.Importance = parseImportance(u.app.Command.DebugColor)
-		buttons = container.NewBorder(nil, nil, nil, debugBtn, runBtn)
-	} else {
-		buttons = runBtn
+		extra.Add(debugBtn)
+	}
+	if u.history != nil {
+		historyBtn := widget.NewButton("History", func() { u.showHistory(u.stateKey) })
+		extra.Add(historyBtn)
 	}
+	extra.Add(widget.NewButton("Jobs", u.showJobs))
 
-	form.Add(buttons)
+	form.Add(container.NewBorder(nil, nil, resetBtn, extra, runBtn))
+
+	u.restoreLastValues()
 
 	// 设置条件监听
 	u.setupConditions()
+	u.setupSuggestions()
 
 	return form
 }
 
 func (u *AppUI) createWidget(item *Item) fyne.CanvasObject {
-	if item.Multi {
+	if item.MultiValued() && item.Type != "multichoice" {
 		return u.createMultiWidget(item)
 	}
 	switch item.Type {
@@ -124,29 +202,8 @@ func (u *AppUI) createWidget(item *Item) fyne.CanvasObject {
 		if item.Default != nil {
 			entry.SetText(fmt.Sprintf("%v", item.Default))
 		}
-		if item.Picker == "file" || item.Picker == "directory" {
-			btnText := item.PickerText
-			if btnText == "" {
-				btnText = "..."
-			}
-			picker := item.Picker
-			btn := widget.NewButton(btnText, func() {
-				if picker == "file" {
-					dialog.ShowFileOpen(func(f fyne.URIReadCloser, err error) {
-						if f != nil {
-							entry.SetText(f.URI().Path())
-							f.Close()
-						}
-					}, u.window)
-				} else {
-					dialog.ShowFolderOpen(func(f fyne.ListableURI, err error) {
-						if f != nil {
-							entry.SetText(f.Path())
-						}
-					}, u.window)
-				}
-			})
-			return container.NewBorder(nil, nil, nil, btn, entry)
+		if pickers[item.Picker] {
+			return u.createPicker(item, entry)
 		}
 		return entry
 	case "number":
@@ -155,7 +212,35 @@ func (u *AppUI) createWidget(item *Item) fyne.CanvasObject {
 			entry.SetText(fmt.Sprintf("%v", item.Default))
 		}
 		return entry
+	case "password":
+		// 密码输入框自带显示/隐藏切换按钮
+		entry := widget.NewPasswordEntry()
+		if item.Default != nil {
+			entry.SetText(fmt.Sprintf("%v", item.Default))
+		}
+		return entry
+	case "text":
+		entry := widget.NewMultiLineEntry()
+		entry.Wrapping = fyne.TextWrapWord
+		rows := item.Rows
+		if rows <= 0 {
+			rows = 3
+		}
+		entry.SetMinRowsVisible(rows)
+		if item.Default != nil {
+			entry.SetText(fmt.Sprintf("%v", item.Default))
+		}
+		return entry
 	case "bool":
+		if item.Tristate {
+			// 再次点击已选项可取消选择，表示使用命令的默认值
+			radio := widget.NewRadioGroup([]string{triStateOn, triStateOff}, nil)
+			radio.Horizontal = true
+			if v, ok := item.Default.(bool); ok {
+				radio.SetSelected(triStateLabel(v))
+			}
+			return radio
+		}
 		check := widget.NewCheck("", nil)
 		if item.Default != nil {
 			if v, ok := item.Default.(bool); ok {
@@ -164,6 +249,9 @@ func (u *AppUI) createWidget(item *Item) fyne.CanvasObject {
 		}
 		return check
 	case "choice":
+		if item.UseRadio() {
+			return u.createChoiceRadio(item)
+		}
 		sel := widget.NewSelect(item.Choices, nil)
 		if item.Placeholder != "" {
 			sel.PlaceHolder = item.Placeholder
@@ -173,126 +261,257 @@ func (u *AppUI) createWidget(item *Item) fyne.CanvasObject {
 		}
 		clearBtn := widget.NewButton("×", func() { sel.ClearSelected() })
 		return container.NewBorder(nil, nil, nil, clearBtn, sel)
+	case "multichoice":
+		return newMultiChoiceWidget(item.Choices, item.DefaultValues())
 	default:
 		return widget.NewEntry()
 	}
 }
 
-func (u *AppUI) createMultiWidget(item *Item) fyne.CanvasObject {
-	mw := &multiWidget{entries: []*widget.Entry{}}
-	mw.vbox = container.NewVBox()
+// choice 的单选按钮组。设置 placeholder 时作为第一个选项表示未选择，否则带清除按钮
+func (u *AppUI) createChoiceRadio(item *Item) fyne.CanvasObject {
+	options := item.Choices
+	if item.Placeholder != "" {
+		options = append([]string{item.Placeholder}, options...)
+	}
+	radio := widget.NewRadioGroup(options, nil)
+	radio.Horizontal = true
+	setRadioChoice(item, radio, "")
+	if item.Default != nil {
+		setRadioChoice(item, radio, fmt.Sprintf("%v", item.Default))
+	}
+	if item.Placeholder != "" {
+		radio.Required = true
+		return radio
+	}
+	clearBtn := widget.NewButton("×", func() { radio.SetSelected("") })
+	return container.NewBorder(nil, nil, nil, clearBtn, radio)
+}
 
-	mw.addEntry = func() {
-		entry := widget.NewEntry()
-		mw.entries = append(mw.entries, entry)
-		removeBtn := widget.NewButton("-", nil)
-		row := container.NewBorder(nil, nil, nil, removeBtn, entry)
-		removeBtn.OnTapped = func() {
-			if len(mw.entries) <= 1 {
-				return
-			}
-			for i, e := range mw.entries {
-				if e == entry {
-					mw.entries = append(mw.entries[:i], mw.entries[i+1:]...)
-					break
-				}
-			}
-			mw.vbox.Remove(row)
-		}
-		mw.vbox.Add(row)
+// 选中 choice 的值，空值或不在 choices 中时选中 placeholder（如有）
+func setRadioChoice(item *Item, radio *widget.RadioGroup, val string) {
+	if !slices.Contains(item.Choices, val) {
+		val = item.Placeholder
 	}
+	radio.SetSelected(val)
+}
 
-	mw.addEntry()
-	addBtn := widget.NewButton("+", func() { mw.addEntry() })
-	mw.vbox.Add(addBtn)
+// 命令预览和日志中代替密码的文本
+const secretMask = "****"
 
-	return mw
+// 预览时代替尚未创建的临时文件路径
+const tempFilePlaceholder = "<temp file>"
+
+func (u *AppUI) BuildArgs() []string {
+	return u.buildArgs(false, nil)
 }
 
-type multiWidget struct {
-	widget.BaseWidget
-	vbox     *fyne.Container
-	entries  []*widget.Entry
-	addEntry func()
+// 密码字段替换为 secretMask 的参数，用于显示和记录
+func (u *AppUI) maskedArgs() []string {
+	return u.buildArgs(true, nil)
 }
 
-func (m *multiWidget) CreateRenderer() fyne.WidgetRenderer {
-	return widget.NewSimpleRenderer(m.vbox)
+// files 为 as_file 字段已写入的临时文件路径
+func (u *AppUI) buildArgs(mask bool, files map[string]string) []string {
+	// 按 position 分组，组内按 order 排序
+	var before, after []argChunk
+	for i := range u.app.Items {
+		item := &u.app.Items[i]
+		if item.IsLabel() {
+			continue
+		}
+		if u.excludedByCondition(item) || item.Type == "text" && item.Stdin {
+			continue
+		}
+		args := u.itemArgs(item, mask, files)
+		if len(args) == 0 {
+			continue
+		}
+		if item.Position == "before" {
+			before = append(before, argChunk{item, args})
+		} else {
+			after = append(after, argChunk{item, args})
+		}
+	}
+	byOrder := func(chunks []argChunk) func(i, j int) bool {
+		return func(i, j int) bool { return chunks[i].item.Order < chunks[j].item.Order }
+	}
+	sort.SliceStable(before, byOrder(before))
+	sort.SliceStable(after, byOrder(after))
+
+	var args []string
+	for _, c := range before {
+		args = append(args, c.args...)
+	}
+	args = append(args, u.app.Command.Args...)
+	if u.app.Command.EndOfOptions && u.needsEndOfOptions(after) {
+		// 位置参数以 - 开头时放到 -- 之后
+		for _, c := range after {
+			if !c.positional() {
+				args = append(args, c.args...)
+			}
+		}
+		// args_after 也是选项，放在 -- 之前
+		args = append(args, u.app.Command.ArgsAfter...)
+		args = append(args, "--")
+		for _, c := range after {
+			if c.positional() {
+				args = append(args, c.args...)
+			}
+		}
+		return args
+	}
+	for _, c := range after {
+		args = append(args, c.args...)
+	}
+	return append(args, u.app.Command.ArgsAfter...)
 }
 
-func (m *multiWidget) MinSize() fyne.Size {
-	return m.vbox.MinSize()
+// 一个字段生成的参数
+type argChunk struct {
+	item *Item
+	args []string
 }
 
-func (m *multiWidget) Resize(size fyne.Size) {
-	m.BaseWidget.Resize(size)
-	m.vbox.Resize(size)
+func (c argChunk) positional() bool {
+	return c.item.Positional && c.item.Template == nil
 }
 
-func (m *multiWidget) Values() []string {
-	var vals []string
-	for _, e := range m.entries {
-		if e.Text != "" {
-			vals = append(vals, e.Text)
+// 是否有以 - 开头的位置参数
+func (u *AppUI) needsEndOfOptions(chunks []argChunk) bool {
+	for _, c := range chunks {
+		if !c.positional() {
+			continue
+		}
+		for _, val := range u.itemValues(c.item) {
+			if strings.HasPrefix(val, "-") {
+				return true
+			}
 		}
 	}
-	return vals
+	return false
 }
 
-func (u *AppUI) BuildArgs() []string {
-	args := append([]string{}, u.app.Command.Args...)
-	for _, item := range u.app.Items {
-		if item.IsLabel() {
-			continue
+// 单个字段生成的参数
+func (u *AppUI) itemArgs(item *Item, mask bool, files map[string]string) []string {
+	var args []string
+	w := u.widgets[item.Name]
+	if item.MultiValued() {
+		vals := u.itemValues(item)
+		if mask && item.IsSecret() {
+			for i := range vals {
+				vals[i] = secretMask
+			}
 		}
-		w := u.widgets[item.Name]
-		if item.Multi {
-			if mw, ok := w.(*multiWidget); ok {
-				for _, val := range mw.Values() {
-					prefix := "--"
-					if item.Short {
-						prefix = "-"
-					}
-					if item.Separator == " " {
-						args = append(args, prefix+item.Name, val)
-					} else if item.Separator == "none" {
-						args = append(args, prefix+item.Name+val)
-					} else if item.Separator == "" {
-						args = append(args, prefix+item.Name+"="+val)
-					} else {
-						args = append(args, prefix+item.Name+item.Separator+val)
-					}
-				}
+		// join 时连接为一个值，按单值字段生成参数
+		if item.Join != "" && len(vals) > 0 {
+			vals = []string{strings.Join(vals, item.Join)}
+		}
+		for _, val := range vals {
+			switch {
+			case item.Template != nil:
+				args = append(args, u.templateArgs(item.Template, val, mask)...)
+			case item.Positional:
+				args = append(args, val)
+			default:
+				args = append(args, flagArgs(item, val)...)
 			}
-			continue
 		}
-		val := u.getWidgetValue(&item, w)
-		if val == "" {
-			continue
+		return args
+	}
+	val := u.expandBatch(u.getWidgetValue(item, w))
+	if item.Type == "bool" {
+		return u.boolArgs(item, val, mask)
+	}
+	if val == "" {
+		return nil
+	}
+	if mask && item.IsSecret() {
+		val = secretMask
+	}
+	if item.Indirect() {
+		val = tempFilePlaceholder
+		if path, ok := files[item.Name]; ok {
+			val = path
 		}
-		if item.Positional {
-			args = append(args, val)
-			continue
+	}
+	if item.Template != nil {
+		return u.templateArgs(item.Template, val, mask)
+	}
+	if item.Positional {
+		return []string{val}
+	}
+	return flagArgs(item, val)
+}
+
+// bool 参数，未勾选时按 off/false_value 生成，tristate 未选择时不生成
+func (u *AppUI) boolArgs(item *Item, val string, mask bool) []string {
+	if val == "true" {
+		switch {
+		case item.Template != nil:
+			return u.templateArgs(item.Template, val, mask)
+		case item.On != nil:
+			return u.templateArgs(item.On, val, mask)
+		case item.TrueValue != "":
+			return flagArgs(item, item.TrueValue)
 		}
-		prefix := "--"
-		if item.Short {
-			prefix = "-"
+		return []string{flagName(item)}
+	}
+	if val == "" && item.Tristate || item.Template != nil {
+		return nil
+	}
+	switch {
+	case item.Off != nil:
+		return u.templateArgs(item.Off, "false", mask)
+	case item.FalseValue != "":
+		return flagArgs(item, item.FalseValue)
+	}
+	return nil
+}
+
+func flagName(item *Item) string {
+	if item.Short {
+		return "-" + item.Name
+	}
+	return "--" + item.Name
+}
+
+// 按分隔符生成带值的参数
+func flagArgs(item *Item, val string) []string {
+	name := flagName(item)
+	switch item.Separator {
+	case " ":
+		return []string{name, val}
+	case "none":
+		return []string{name + val}
+	case "":
+		return []string{name + "=" + val}
+	}
+	return []string{name + item.Separator + val}
+}
+
+// 按模板生成参数，引用的其他字段为空时整个模板不生成
+func (u *AppUI) templateArgs(tmpl []string, val string, mask bool) []string {
+	empty := false
+	lookup := func(ref string) string {
+		name, mod := splitTemplateRef(ref)
+		if name == "value" {
+			return applyPathModifier(val, mod)
 		}
-		if item.Type == "bool" {
-			if val == "true" {
-				args = append(args, prefix+item.Name)
-			}
-		} else {
-			if item.Separator == " " {
-				args = append(args, prefix+item.Name, val)
-			} else if item.Separator == "none" {
-				args = append(args, prefix+item.Name+val)
-			} else if item.Separator == "" {
-				args = append(args, prefix+item.Name+"="+val)
-			} else {
-				args = append(args, prefix+item.Name+item.Separator+val)
-			}
+		v := u.templateValue(ref)
+		if v == "" {
+			empty = true
+		} else if item := u.findItem(name); mask && item != nil && item.IsSecret() {
+			v = secretMask
 		}
+		return v
+	}
+	args := make([]string, 0, len(tmpl))
+	for _, tok := range tmpl {
+		args = append(args, expandTemplate(tok, lookup))
+	}
+	if empty {
+		return nil
 	}
 	return args
 }
@@ -300,7 +519,12 @@ func (u *AppUI) BuildArgs() []string {
 func (u *AppUI) getWidgetValue(item *Item, w fyne.CanvasObject) string {
 	var val string
 	switch item.Type {
-	case "string", "number":
+	case "text":
+		// 多行文本按原样传递，不去引号
+		if entry, ok := w.(*widget.Entry); ok {
+			return entry.Text
+		}
+	case "string", "number", "password":
 		if entry, ok := w.(*widget.Entry); ok {
 			val = entry.Text
 		} else if c, ok := w.(*fyne.Container); ok {
@@ -312,20 +536,38 @@ func (u *AppUI) getWidgetValue(item *Item, w fyne.CanvasObject) string {
 			}
 		}
 	case "bool":
-		if w.(*widget.Check).Checked {
-			return "true"
+		switch wt := w.(type) {
+		case *widget.Check:
+			if wt.Checked {
+				return "true"
+			}
+		case *widget.RadioGroup:
+			switch wt.Selected {
+			case triStateOn:
+				return "true"
+			case triStateOff:
+				return "false"
+			}
 		}
 		return ""
 	case "choice":
-		if sel, ok := w.(*widget.Select); ok {
-			return sel.Selected
-		} else if c, ok := w.(*fyne.Container); ok {
+		if c, ok := w.(*fyne.Container); ok {
 			for _, obj := range c.Objects {
-				if sel, ok := obj.(*widget.Select); ok {
-					return sel.Selected
+				switch obj.(type) {
+				case *widget.Select, *widget.RadioGroup:
+					w = obj
 				}
 			}
 		}
+		switch wt := w.(type) {
+		case *widget.Select:
+			return wt.Selected
+		case *widget.RadioGroup:
+			if wt.Selected == item.Placeholder {
+				return ""
+			}
+			return wt.Selected
+		}
 	}
 	// trim quotes
 	if len(val) >= 2 {
@@ -336,8 +578,85 @@ func (u *AppUI) getWidgetValue(item *Item, w fyne.CanvasObject) string {
 	return val
 }
 
+// 字段当前的值，多值字段返回全部非空值
+func (u *AppUI) itemValues(item *Item) []string {
+	if u.batchInput != "" && item.Name == u.app.Batch.Item {
+		return []string{u.batchInput}
+	}
+	w := u.widgets[item.Name]
+	if vw, ok := w.(valuesWidget); ok {
+		vals := vw.Values()
+		for i := range vals {
+			vals[i] = u.expandBatch(vals[i])
+		}
+		return vals
+	}
+	if val := u.expandBatch(u.getWidgetValue(item, w)); val != "" {
+		return []string{val}
+	}
+	return []string{}
+}
+
+// 设置 widget 的值，值为空时清空
+func (u *AppUI) setWidgetValues(item *Item, w fyne.CanvasObject, vals []string) {
+	if vw, ok := w.(valuesWidget); ok {
+		vw.SetValues(vals)
+		return
+	}
+	val := ""
+	if len(vals) > 0 {
+		val = vals[len(vals)-1]
+	}
+	switch wt := w.(type) {
+	case *widget.Entry:
+		wt.SetText(val)
+	case *widget.Check:
+		wt.SetChecked(val == "true")
+	case *widget.RadioGroup:
+		if item.Type == "choice" {
+			setRadioChoice(item, wt, val)
+			return
+		}
+		switch val {
+		case "true", "false":
+			wt.SetSelected(triStateLabel(val == "true"))
+		default:
+			wt.SetSelected("")
+		}
+	case *widget.Select:
+		setSelectValue(wt, val)
+	case *fyne.Container:
+		for _, obj := range wt.Objects {
+			switch o := obj.(type) {
+			case *widget.Entry:
+				o.SetText(val)
+				return
+			case *widget.Select:
+				setSelectValue(o, val)
+				return
+			case *widget.RadioGroup:
+				setRadioChoice(item, o, val)
+				return
+			}
+		}
+	}
+}
+
+func setSelectValue(sel *widget.Select, val string) {
+	if val == "" {
+		sel.ClearSelected()
+	} else {
+		sel.SetSelected(val)
+	}
+}
+
+// 用于显示的命令行，密码字段已隐藏
 func (u *AppUI) buildCommandLine() string {
-	args := u.BuildArgs()
+	return quoteCommandLine(u.app.Command.Path, u.maskedArgs())
+}
+
+// 拼接命令行，含空格或引号的参数加引号
+func quoteCommandLine(path string, args []string) string {
 	quote := "'"
 	if runtime.GOOS == "windows" {
 		quote = "\""
@@ -349,17 +668,46 @@ func (u *AppUI) buildCommandLine() string {
 		}
 		quoted = append(quoted, arg)
 	}
-	return u.app.Command.Path + " " + strings.Join(quoted, " ")
+	return path + " " + strings.Join(quoted, " ")
 }
 
 func (u *AppUI) showCommand() {
+	// 批量执行时显示第一个输入的命令
+	header := ""
+	if item := u.batchItem(); item != nil {
+		if inputs, err := expandBatchInputs(u.itemValues(item), u.app.Batch.Glob); err == nil {
+			u.batchInput = inputs[0]
+			header = fmt.Sprintf("Batch: %d inputs, showing the first\n", len(inputs))
+		}
+	}
 	cmdLine := u.buildCommandLine()
+	fullLine := quoteCommandLine(u.app.Command.Path, u.BuildArgs())
+	dir := u.expandDir()
+	u.batchInput = ""
 	entry := widget.NewEntry()
 	entry.SetText(cmdLine)
-	d := dialog.NewCustomConfirm("Command", "Copy", "Close", entry, func(copy bool) {
-		if copy {
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	content := container.NewBorder(widget.NewLabel(header+"Directory: "+dir), nil, nil, nil, entry)
+	d := dialog.NewCustomConfirm("Command", "Copy", "Close", content, func(copy bool) {
+		if !copy {
+			return
+		}
+		if fullLine == cmdLine {
 			u.window.Clipboard().SetContent(cmdLine)
+			return
 		}
+		// 含有密码时询问是否一并复制
+		dialog.ShowCustomConfirm("Copy Command", "Include", "Mask",
+			widget.NewLabel("The command contains secret values. Include them in the copied text?"),
+			func(include bool) {
+				if include {
+					u.window.Clipboard().SetContent(fullLine)
+				} else {
+					u.window.Clipboard().SetContent(cmdLine)
+				}
+			}, u.window)
 	}, u.window)
 	size := u.window.Canvas().Size()
 	d.Resize(fyne.NewSize(size.Width*2/3, size.Height*2/3))
@@ -423,8 +771,7 @@ func (u *AppUI) validateRequired() error {
 		if !u.checkCondition(&item) {
 			continue
 		}
-		val := u.getWidgetValue(&item, u.widgets[item.Name])
-		if val == "" {
+		if len(u.truthValues(&item)) == 0 {
 			label := item.Label
 			if label == "" {
 				label = item.Name
@@ -444,8 +791,13 @@ func (u *AppUI) validateAll() error {
 		if !u.checkCondition(&item) {
 			continue
 		}
-		val := u.getWidgetValue(&item, u.widgets[item.Name])
-		if err := u.validateItem(&item, val); err != nil {
+		vals := u.itemValues(&item)
+		for _, val := range vals {
+			if err := u.validateItem(&item, val); err != nil {
+				return err
+			}
+		}
+		if err := validateCount(&item, len(vals)); err != nil {
 			return err
 		}
 	}
@@ -493,6 +845,24 @@ func (u *AppUI) validateItem(item *Item, val string) error {
 	return nil
 }
 
+// 验证多值字段的值个数，空值不计入
+func validateCount(item *Item, n int) error {
+	if !item.MultiValued() || n == 0 && !item.Required {
+		return nil
+	}
+	label := item.Label
+	if label == "" {
+		label = item.Name
+	}
+	if n < item.MinCount {
+		return fmt.Errorf("%s needs at least %d values", label, item.MinCount)
+	}
+	if item.MaxCount > 0 && n > item.MaxCount {
+		return fmt.Errorf("%s allows at most %d values", label, item.MaxCount)
+	}
+	return nil
+}
+
 func toFloat(v any) (float64, bool) {
 	switch n := v.(type) {
 	case int:
@@ -505,42 +875,50 @@ func toFloat(v any) (float64, bool) {
 	return 0, false
 }
 
-// 解析条件
-func parseCondition(cond string) (field, op, value string) {
-	if i := strings.Index(cond, "!="); i > 0 {
-		return cond[:i], "!=", cond[i+2:]
-	}
-	if i := strings.Index(cond, "="); i > 0 {
-		return cond[:i], "=", cond[i+1:]
-	}
-	return cond, "!=", ""
-}
-
-// 检查条件是否满足
+// 检查条件是否满足，表达式无效或引用了不存在的字段时视为满足
 func (u *AppUI) checkCondition(item *Item) bool {
 	if item.Condition == "" {
 		return true
 	}
-	field, op, expected := parseCondition(item.Condition)
-	w := u.widgets[field]
-	if w == nil {
+	expr, err := compileCondition(item.Condition)
+	if err != nil {
 		return true
 	}
-	var fieldItem *Item
-	for i := range u.app.Items {
-		if u.app.Items[i].Name == field {
-			fieldItem = &u.app.Items[i]
-			break
+	for _, field := range conditionFields(expr) {
+		if u.widgets[field] == nil || u.findItem(field) == nil {
+			return true
 		}
 	}
-	if fieldItem == nil {
-		return true
+	return expr.eval(formCondition{u})
+}
+
+// 条件中读取表单字段
+type formCondition struct{ u *AppUI }
+
+func (c formCondition) values(field string) []string {
+	return c.u.itemValues(c.u.findItem(field))
+}
+
+func (c formCondition) isSet(field string) bool {
+	return len(c.u.truthValues(c.u.findItem(field))) > 0
+}
+
+// 判断字段是否有值时使用: tristate 为 Off 时仍生成参数，但视为空
+func (u *AppUI) truthValues(item *Item) []string {
+	vals := u.itemValues(item)
+	if item.Type == "bool" && slices.Equal(vals, []string{"false"}) {
+		return nil
 	}
-	actual := u.getWidgetValue(fieldItem, w)
-	if op == "!=" {
-		return actual != expected
+	return vals
+}
+
+func (u *AppUI) findItem(name string) *Item {
+	for i := range u.app.Items {
+		if u.app.Items[i].Name == name {
+			return &u.app.Items[i]
+		}
 	}
-	return actual == expected
+	return nil
 }
 
 // 设置条件监听
@@ -552,8 +930,13 @@ func (u *AppUI) setupConditions() {
 		if item.Condition == "" {
 			continue
 		}
-		field, _, _ := parseCondition(item.Condition)
-		deps[field] = append(deps[field], item)
+		expr, err := compileCondition(item.Condition)
+		if err != nil {
+			continue
+		}
+		for _, field := range conditionFields(expr) {
+			deps[field] = append(deps[field], item)
+		}
 	}
 
 	// 为每个被依赖的字段添加监听
@@ -568,55 +951,125 @@ func (u *AppUI) setupConditions() {
 				u.updateWidgetState(item)
 			}
 		}
-		// 根据 widget 类型添加监听
-		switch wt := w.(type) {
-		case *widget.Entry:
-			wt.OnChanged = func(s string) { updateFunc() }
-		case *widget.Select:
-			wt.OnChanged = func(s string) { updateFunc() }
-		case *widget.Check:
-			wt.OnChanged = func(b bool) { updateFunc() }
-		case *fyne.Container:
-			for _, obj := range wt.Objects {
-				if entry, ok := obj.(*widget.Entry); ok {
-					entry.OnChanged = func(s string) { updateFunc() }
-					break
-				}
-				if sel, ok := obj.(*widget.Select); ok {
-					sel.OnChanged = func(s string) { updateFunc() }
-					break
-				}
-			}
-		}
+		u.watch(field, updateFunc)
 		// 初始化状态
 		updateFunc()
 	}
 }
 
-// 更新 widget 启用/禁用状态
+// 监听字段值的变化，同一字段可以有多个回调
+func (u *AppUI) watch(field string, fn func()) {
+	w := u.widgets[field]
+	if w == nil {
+		return
+	}
+	if len(u.watchers[field]) == 0 {
+		onWidgetChanged(w, func() {
+			for _, fn := range u.watchers[field] {
+				fn()
+			}
+		})
+	}
+	u.watchers[field] = append(u.watchers[field], fn)
+}
+
+// 条件不满足的字段不生成参数，禁用模式下可以用 emit_when_disabled 保留
+func (u *AppUI) excludedByCondition(item *Item) bool {
+	if u.checkCondition(item) {
+		return false
+	}
+	return !item.EmitWhenDisabled || u.app.HideWhenFalse(item)
+}
+
+// 更新 widget 启用/禁用或显示/隐藏状态
 func (u *AppUI) updateWidgetState(item *Item) {
 	w := u.widgets[item.Name]
 	if w == nil {
 		return
 	}
 	enabled := u.checkCondition(item)
-	if dw, ok := w.(fyne.Disableable); ok {
-		if enabled {
-			dw.Enable()
-		} else {
-			dw.Disable()
-		}
-	}
-	// 处理 container 类型 (picker)
-	if c, ok := w.(*fyne.Container); ok {
-		for _, obj := range c.Objects {
-			if dw, ok := obj.(fyne.Disableable); ok {
+	if u.app.HideWhenFalse(item) {
+		changed := false
+		for _, row := range u.rows[item.Name] {
+			if row.Visible() != enabled {
+				changed = true
 				if enabled {
-					dw.Enable()
+					row.Show()
 				} else {
-					dw.Disable()
+					row.Hide()
 				}
 			}
 		}
+		if changed && u.form != nil {
+			u.form.Refresh()
+		}
+		return
+	}
+	setObjectEnabled(w, enabled)
+}
+
+const dirPickerLabel = "Working Directory"
+
+// 工作目录选择器，初始值为 command.dir
+func (u *AppUI) createDirPicker() fyne.CanvasObject {
+	u.dirEntry = widget.NewEntry()
+	u.dirEntry.SetText(u.app.Command.Dir)
+	u.dirEntry.SetPlaceHolder("Current directory")
+	btn := widget.NewButton("...", func() {
+		dialog.ShowFolderOpen(func(f fyne.ListableURI, err error) {
+			if f != nil {
+				u.dirEntry.SetText(f.Path())
+			}
+		}, u.window)
+	})
+	return container.NewBorder(nil, nil, nil, btn, u.dirEntry)
+}
+
+// 展开后的工作目录，为空时使用当前目录
+func (u *AppUI) expandDir() string {
+	dir := u.app.Command.Dir
+	if u.dirEntry != nil {
+		dir = u.dirEntry.Text
+	}
+	if dir == "" {
+		return ""
+	}
+	return expandPath(dir, u.pathValue)
+}
+
+// 路径中 $name 引用的字段值，不是字段时返回 false 以使用环境变量
+func (u *AppUI) pathValue(ref string) (string, bool) {
+	if v, ok := u.batchValue(ref); ok {
+		return v, true
+	}
+	name, _ := splitTemplateRef(ref)
+	if item := u.findItem(name); item != nil && !item.IsLabel() {
+		return u.templateValue(ref), true
+	}
+	return "", false
+}
+
+// 检查工作目录是否存在
+func (u *AppUI) workDir() (string, error) {
+	dir := u.expandDir()
+	if dir == "" {
+		return "", nil
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("working directory %q does not exist", dir)
+	}
+	return dir, nil
+}
+
+// tristate bool 的选项
+const (
+	triStateOn  = "On"
+	triStateOff = "Off"
+)
+
+func triStateLabel(v bool) string {
+	if v {
+		return triStateOn
 	}
+	return triStateOff
 }
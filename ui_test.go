@@ -124,7 +124,7 @@ func TestBuildArgsChoice(t *testing.T) {
 	ui := NewAppUI(app, w)
 	ui.Build()
 
-	ui.widgets["format"].(*widget.Select).SetSelected("json")
+	setSelected(ui.widgets["format"], "json")
 
 	args := ui.BuildArgs()
 	want := []string{"--format=json"}
@@ -143,9 +143,9 @@ func TestBuildArgsMulti(t *testing.T) {
 	ui.Build()
 
 	mw := ui.widgets["H"].(*multiWidget)
-	mw.entries[0].SetText("Content-Type: application/json")
-	mw.addEntry()
-	mw.entries[1].SetText("Authorization: Bearer token")
+	setEntryText(mw.rows[0], "Content-Type: application/json")
+	mw.addRow()
+	setEntryText(mw.rows[1], "Authorization: Bearer token")
 
 	args := ui.BuildArgs()
 	want := []string{"-H", "Content-Type: application/json", "-H", "Authorization: Bearer token"}
@@ -252,6 +252,353 @@ func TestBuildArgsLabel(t *testing.T) {
 	}
 }
 
+func TestBuildArgsConditionFalse(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "format", Type: "choice", Choices: []string{"json", "text"}},
+			{Name: "indent", Type: "number", Default: int64(2), Condition: "format=json"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	// 条件不满足时默认值不应出现在参数中
+	setSelected(ui.widgets["format"], "text")
+	args := ui.BuildArgs()
+	want := []string{"--format=text"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+
+	setSelected(ui.widgets["format"], "json")
+	args = ui.BuildArgs()
+	want = []string{"--format=json", "--indent=2"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestBuildArgsConditionMulti(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "headers", Type: "bool"},
+			{Name: "H", Type: "string", Short: true, Multi: true, Separator: " ", Condition: "headers"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	setEntryText(ui.widgets["H"].(*multiWidget).rows[0], "Accept: */*")
+	if args := ui.BuildArgs(); len(args) != 0 {
+		t.Errorf("BuildArgs() = %v, want []", args)
+	}
+
+	ui.widgets["headers"].(*widget.Check).SetChecked(true)
+	args := ui.BuildArgs()
+	want := []string{"--headers", "-H", "Accept: */*"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestBuildArgsConditionBool(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "output", Type: "string"},
+			{Name: "force", Type: "bool", Default: true, Condition: "output"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	// bool 默认选中，但条件不满足
+	if args := ui.BuildArgs(); len(args) != 0 {
+		t.Errorf("BuildArgs() = %v, want []", args)
+	}
+
+	setEntryText(ui.widgets["output"], "out.txt")
+	args := ui.BuildArgs()
+	want := []string{"--output=out.txt", "--force"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestBuildArgsEmitWhenDisabled(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "advanced", Type: "bool"},
+			{Name: "level", Type: "number", Default: int64(3), Condition: "advanced", EmitWhenDisabled: true},
+			{Name: "mode", Type: "string", Default: "fast", Condition: "advanced", ConditionMode: "hide", EmitWhenDisabled: true},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	// 禁用的字段保留参数，隐藏的字段仍然跳过
+	args := ui.BuildArgs()
+	want := []string{"--level=3"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestBuildArgsPassword(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "curl"},
+		Items: []Item{
+			{Name: "H", Type: "password", Short: true, Separator: " "},
+			{Name: "url", Type: "string", Positional: true},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	token, ok := ui.widgets["H"].(*widget.Entry)
+	if !ok || !token.Password {
+		t.Fatalf("password widget = %T, want masked *widget.Entry", ui.widgets["H"])
+	}
+	token.SetText("Authorization: Bearer abc")
+	setEntryText(ui.widgets["url"], "https://example.com")
+
+	args := ui.BuildArgs()
+	want := []string{"-H", "Authorization: Bearer abc", "https://example.com"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+	masked := ui.maskedArgs()
+	want = []string{"-H", "****", "https://example.com"}
+	if !reflect.DeepEqual(masked, want) {
+		t.Errorf("maskedArgs() = %v, want %v", masked, want)
+	}
+	if line := ui.buildCommandLine(); strings.Contains(line, "abc") {
+		t.Errorf("buildCommandLine() = %q, should not contain the secret", line)
+	}
+}
+
+func TestBuildArgsText(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "git", Args: []string{"commit"}},
+		Items: []Item{
+			{Name: "m", Type: "text", Short: true, Separator: " ", Rows: 5},
+			{Name: "body", Type: "text", Stdin: true},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	msg := ui.widgets["m"].(*widget.Entry)
+	if !msg.MultiLine {
+		t.Fatal("text item should be a multi-line entry")
+	}
+	// 多行文本原样传递，包括引号
+	msg.SetText("\"Fix\" parser\n\nDetails")
+	ui.widgets["body"].(*widget.Entry).SetText("ignored")
+
+	args := ui.BuildArgs()
+	want := []string{"commit", "-m", "\"Fix\" parser\n\nDetails"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestBuildArgsTemplate(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "ffmpeg"},
+		Items: []Item{
+			{Name: "define", Type: "string", Multi: true, Template: []string{"-D", "${value}"}},
+			{Name: "tag", Type: "string", Template: []string{"--set=image.tag=${value}"}},
+			{Name: "scale", Type: "bool", Template: []string{"-vf", "scale=${width}:${height}"}},
+			{Name: "width", Type: "number", Template: []string{}},
+			{Name: "height", Type: "number", Positional: true, Template: []string{}},
+			{Name: "token", Type: "password", Template: []string{"--auth", "${user}:${value}"}},
+			{Name: "user", Type: "string", Template: []string{}},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	mw := ui.widgets["define"].(*multiWidget)
+	setEntryText(mw.rows[0], "a=1")
+	mw.addRow()
+	setEntryText(mw.rows[1], "b=2")
+	setEntryText(ui.widgets["tag"], "1.2")
+	ui.widgets["scale"].(*widget.Check).SetChecked(true)
+	setEntryText(ui.widgets["width"], "1280")
+	setEntryText(ui.widgets["token"], "s3cret")
+	setEntryText(ui.widgets["user"], "bob")
+
+	// height 为空时 scale 模板整个跳过
+	args := ui.BuildArgs()
+	want := []string{"-D", "a=1", "-D", "b=2", "--set=image.tag=1.2", "--auth", "bob:s3cret"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+
+	setEntryText(ui.widgets["height"], "720")
+	args = ui.maskedArgs()
+	want = []string{"-D", "a=1", "-D", "b=2", "--set=image.tag=1.2", "-vf", "scale=1280:720", "--auth", "bob:****"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("maskedArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestBuildArgsOrder(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "ffmpeg", Args: []string{"-y"}, ArgsAfter: []string{"-f", "mp4"}},
+		Items: []Item{
+			{Name: "output", Type: "string", Positional: true, Order: 10},
+			{Name: "c:v", Type: "string", Short: true, Separator: " "},
+			{Name: "i", Type: "string", Short: true, Separator: " ", Order: -1},
+			{Name: "hide_banner", Type: "bool", Short: true, Position: "before"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	setEntryText(ui.widgets["output"], "out.mp4")
+	setEntryText(ui.widgets["c:v"], "libx264")
+	setEntryText(ui.widgets["i"], "in.mov")
+	ui.widgets["hide_banner"].(*widget.Check).SetChecked(true)
+
+	args := ui.BuildArgs()
+	want := []string{"-hide_banner", "-y", "-i", "in.mov", "-c:v", "libx264", "out.mp4", "-f", "mp4"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestBuildArgsEndOfOptions(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "rm", EndOfOptions: true},
+		Items: []Item{
+			{Name: "file", Type: "string", Positional: true},
+			{Name: "f", Type: "bool", Short: true},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	setEntryText(ui.widgets["file"], "notes.txt")
+	ui.widgets["f"].(*widget.Check).SetChecked(true)
+	want := []string{"notes.txt", "-f"}
+	if args := ui.BuildArgs(); !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+
+	// 以 - 开头的位置参数放到 -- 之后
+	setEntryText(ui.widgets["file"], "-rf")
+	want = []string{"-f", "--", "-rf"}
+	if args := ui.BuildArgs(); !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+
+	// args_after 放在 -- 之前，不作为位置参数
+	app.Command.ArgsAfter = []string{"-o", "out"}
+	want = []string{"-f", "-o", "out", "--", "-rf"}
+	if args := ui.BuildArgs(); !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() with args_after = %v, want %v", args, want)
+	}
+}
+
+func TestBuildArgsMultiBool(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items:   []Item{{Name: "v", Type: "bool", Short: true, Multi: true}},
+	}
+	ui := NewAppUI(app, test.NewWindow(nil))
+	ui.Build()
+
+	// bool 忽略 multi，仍为单个复选框
+	check, ok := ui.widgets["v"].(*widget.Check)
+	if !ok {
+		t.Fatalf("widget = %T, want *widget.Check", ui.widgets["v"])
+	}
+	check.SetChecked(true)
+	want := []string{"-v"}
+	if args := ui.BuildArgs(); !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestBuildArgsBoolValues(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "color", Type: "bool", Off: []string{"--no-color"}},
+			{Name: "cache", Type: "bool", TrueValue: "true", FalseValue: "false"},
+			{Name: "v", Type: "bool", Short: true, Separator: " ", TrueValue: "1", FalseValue: "0"},
+			{Name: "mode", Type: "bool", On: []string{"--fast"}, Off: []string{"--slow"}},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	want := []string{"--no-color", "--cache=false", "-v", "0", "--slow"}
+	if args := ui.BuildArgs(); !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+	for _, name := range []string{"color", "cache", "v", "mode"} {
+		ui.widgets[name].(*widget.Check).SetChecked(true)
+	}
+	want = []string{"--color", "--cache=true", "-v", "1", "--fast"}
+	if args := ui.BuildArgs(); !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestBuildArgsTristate(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "color", Type: "bool", Tristate: true, Off: []string{"--no-color"}},
+			{Name: "extra", Type: "string", Condition: "color=false"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	radio, ok := ui.widgets["color"].(*widget.RadioGroup)
+	if !ok {
+		t.Fatalf("tristate widget = %T, want *widget.RadioGroup", ui.widgets["color"])
+	}
+	// 未选择时使用命令的默认值
+	if args := ui.BuildArgs(); len(args) != 0 {
+		t.Errorf("BuildArgs() = %v, want []", args)
+	}
+	radio.SetSelected(triStateOn)
+	if args := ui.BuildArgs(); !reflect.DeepEqual(args, []string{"--color"}) {
+		t.Errorf("BuildArgs() = %v, want [--color]", args)
+	}
+	radio.SetSelected(triStateOff)
+	if args := ui.BuildArgs(); !reflect.DeepEqual(args, []string{"--no-color"}) {
+		t.Errorf("BuildArgs() = %v, want [--no-color]", args)
+	}
+	if ui.widgets["extra"].(*widget.Entry).Disabled() {
+		t.Error("extra should be enabled when color=false")
+	}
+
+	ui.setWidgetValues(&app.Items[0], radio, nil)
+	if radio.Selected != "" {
+		t.Errorf("Selected = %q, want unset", radio.Selected)
+	}
+}
+
 func setEntryText(w interface{}, text string) {
 	switch v := w.(type) {
 	case *widget.Entry:
@@ -259,6 +606,19 @@ func setEntryText(w interface{}, text string) {
 	}
 }
 
+func setSelected(w interface{}, val string) {
+	switch v := w.(type) {
+	case *widget.Select:
+		v.SetSelected(val)
+	case *fyne.Container:
+		for _, obj := range v.Objects {
+			if sel, ok := obj.(*widget.Select); ok {
+				sel.SetSelected(val)
+			}
+		}
+	}
+}
+
 func TestBuildUI_SingleApp(t *testing.T) {
 	cfg := &Config{
 		Apps: []App{{Command: Command{Path: "cmd", Name: "Test"}}},
@@ -449,10 +809,10 @@ func TestMultiWidgetValues(t *testing.T) {
 	}
 
 	// 添加值
-	mw.entries[0].SetText("tag1")
-	mw.addEntry()
-	mw.entries[1].SetText("tag2")
-	mw.addEntry()
+	setEntryText(mw.rows[0], "tag1")
+	mw.addRow()
+	setEntryText(mw.rows[1], "tag2")
+	mw.addRow()
 	// 第三个保持空
 
 	vals = mw.Values()
@@ -500,3 +860,200 @@ func TestGetWidgetValueFromContainer(t *testing.T) {
 		t.Errorf("BuildArgs() = %v, want %v", args, want)
 	}
 }
+
+func TestMultiWidgetTypes(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "in", Type: "string", Multi: true, Picker: "file"},
+			{Name: "mode", Type: "choice", Multi: true, Choices: []string{"a", "b"}},
+			{Name: "n", Type: "number", Multi: true, Max: 10},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	in := ui.widgets["in"].(*multiWidget)
+	if _, ok := in.rows[0].(*fyne.Container); !ok {
+		t.Errorf("file row = %T, want picker container", in.rows[0])
+	}
+	in.SetValues([]string{"a.txt", "b.txt"})
+	mode := ui.widgets["mode"].(*multiWidget)
+	setSelected(mode.rows[0], "b")
+	setSelected(mode.addRow(), "a")
+
+	want := []string{"--in=a.txt", "--in=b.txt", "--mode=b", "--mode=a"}
+	if got := ui.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+
+	// 每行单独验证
+	ui.widgets["n"].(*multiWidget).SetValues([]string{"5", "20"})
+	if err := ui.validateAll(); err == nil || !strings.Contains(err.Error(), "<= 10") {
+		t.Errorf("validateAll() = %v, want range error", err)
+	}
+}
+
+func TestMultiWidgetReorder(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items:   []Item{{Name: "f", Type: "string", Multi: true, Positional: true}},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	mw := ui.widgets["f"].(*multiWidget)
+	mw.SetValues([]string{"a", "b", "c"})
+	mw.moveRow(mw.rows[2], -1)
+	mw.moveRow(mw.rows[0], -1) // 已在第一行
+	want := []string{"a", "c", "b"}
+	if got := ui.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+	mw.removeRow(mw.rows[0])
+	if got := mw.Values(); !reflect.DeepEqual(got, []string{"c", "b"}) {
+		t.Errorf("Values() = %v, want [c b]", got)
+	}
+}
+
+func TestMultiWidgetCount(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items:   []Item{{Name: "f", Type: "string", Multi: true, MinCount: 2, MaxCount: 3, Required: true}},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	mw := ui.widgets["f"].(*multiWidget)
+	if len(mw.rows) != 2 {
+		t.Fatalf("rows = %d, want min_count rows", len(mw.rows))
+	}
+	mw.removeRow(mw.rows[0])
+	if len(mw.rows) != 2 {
+		t.Errorf("rows = %d after remove, want 2", len(mw.rows))
+	}
+	mw.addRow()
+	if !mw.addBtn.Disabled() {
+		t.Error("add button should be disabled at max_count")
+	}
+
+	setEntryText(mw.rows[0], "a")
+	if err := ui.validateAll(); err == nil || !strings.Contains(err.Error(), "at least 2") {
+		t.Errorf("validateAll() = %v, want min_count error", err)
+	}
+	setEntryText(mw.rows[2], "b")
+	if err := ui.validateAll(); err != nil {
+		t.Errorf("validateAll() = %v", err)
+	}
+}
+
+func TestMultiWidgetJoin(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "tags", Type: "string", Multi: true, Join: ","},
+			{Name: "key", Type: "password", Multi: true, Join: ":"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	ui.widgets["tags"].(*multiWidget).SetValues([]string{"a", "b", "c"})
+	ui.widgets["key"].(*multiWidget).SetValues([]string{"x", "y"})
+	want := []string{"--tags=a,b,c", "--key=x:y"}
+	if got := ui.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+	want = []string{"--tags=a,b,c", "--key=****:****"}
+	if got := ui.maskedArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("maskedArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestMultiChoice(t *testing.T) {
+	many := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "features", Type: "multichoice", Choices: []string{"x", "y", "z"}, Join: ",", Default: []any{"z", "x"}},
+			{Name: "pkg", Type: "multichoice", Choices: many, Positional: true},
+			{Name: "extra", Type: "string", Condition: "features ~ y"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	features := ui.widgets["features"].(*multiChoiceWidget)
+	if features.group == nil {
+		t.Error("short choices should use a check group")
+	}
+	pkg := ui.widgets["pkg"].(*multiChoiceWidget)
+	if pkg.list == nil {
+		t.Fatal("long choices should use a searchable list")
+	}
+	pkg.search.SetText("J")
+	if !reflect.DeepEqual(pkg.filtered, []string{"j"}) {
+		t.Errorf("filtered = %v, want [j]", pkg.filtered)
+	}
+	pkg.SetValues([]string{"j", "b", "unknown"})
+
+	want := []string{"--features=x,z", "b", "j"}
+	if got := ui.BuildArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+
+	if !ui.widgets["extra"].(*widget.Entry).Disabled() {
+		t.Error("extra should be disabled while y is not selected")
+	}
+	features.group.SetSelected([]string{"x", "y"})
+	if ui.widgets["extra"].(*widget.Entry).Disabled() {
+		t.Error("extra should be enabled after selecting y")
+	}
+}
+
+func TestChoiceRadio(t *testing.T) {
+	app := &App{
+		Command: Command{Path: "cmd"},
+		Items: []Item{
+			{Name: "fmt", Type: "choice", Style: "auto", Choices: []string{"json", "xml"}, Default: "xml"},
+			{Name: "level", Type: "choice", Style: "radio", Choices: []string{"low", "high"}, Placeholder: "Default"},
+			{Name: "big", Type: "choice", Style: "auto", Choices: []string{"1", "2", "3", "4", "5", "6"}},
+			{Name: "indent", Type: "number", Condition: "fmt=json"},
+		},
+	}
+	w := test.NewWindow(nil)
+	ui := NewAppUI(app, w)
+	ui.Build()
+
+	if _, ok := ui.widgets["big"].(*fyne.Container).Objects[0].(*widget.Select); !ok {
+		t.Error("auto with many choices should use a select")
+	}
+	level := ui.widgets["level"].(*widget.RadioGroup)
+	if level.Selected != "Default" {
+		t.Errorf("level selected = %q, want placeholder", level.Selected)
+	}
+	if got := ui.BuildArgs(); !reflect.DeepEqual(got, []string{"--fmt=xml"}) {
+		t.Errorf("BuildArgs() = %v, want [--fmt=xml]", got)
+	}
+
+	// 条件监听单选按钮的变化
+	fmtRadio := ui.widgets["fmt"].(*fyne.Container).Objects[0].(*widget.RadioGroup)
+	fmtRadio.SetSelected("json")
+	if ui.widgets["indent"].(*widget.Entry).Disabled() {
+		t.Error("indent should be enabled for json")
+	}
+
+	ui.setWidgetValues(&app.Items[1], level, []string{"high"})
+	if got := ui.getWidgetValue(&app.Items[1], level); got != "high" {
+		t.Errorf("level = %q, want high", got)
+	}
+	ui.setWidgetValues(&app.Items[1], level, nil)
+	if level.Selected != "Default" || ui.getWidgetValue(&app.Items[1], level) != "" {
+		t.Errorf("cleared level selected = %q, want placeholder", level.Selected)
+	}
+}